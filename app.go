@@ -3,7 +3,9 @@ package x
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -15,29 +17,42 @@ import (
 
 // 앱 구조체
 type App struct {
-	Server          *http.Server
-	Initialize      func()
-	Finalize        func()
-	OnShutdownErr   func(error)
-	OnSignal        map[os.Signal]func()
-	OnUnknownSignal func(os.Signal)
-	Conns           map[string]*sql.DB
-	Router          *Router
-	Logger          *Logger
+	Server             *http.Server
+	Initialize         func()
+	Finalize           func()
+	OnShutdownErr      func(error)
+	OnSignal           map[os.Signal]func()
+	OnUnknownSignal    func(os.Signal)
+	Conns              map[string]*sql.DB
+	Router             *Router
+	Logger             *Logger
+	Reporter           ErrorReporter   // nil 이면 보고하지 않음
+	ProblemBaseURI     string          // problem+json 의 type 접두사 (type = ProblemBaseURI + Code)
+	Templates          *Templates      // ReplyHTML 용 템플릿 (nil 이면 사용 안함)
+	MaxBodySize        int64           // 요청 본문 최대 크기 (0 이하면 제한 없음)
+	MaxDecompressRatio int             // 압축 해제 시 원본 대비 최대 배율 (0 이면 제한 없음)
+	TrustedProxies     []*net.IPNet    // 프록시 헤더를 믿을 피어 대역 (SetTrustedProxies)
+	ProxyHeader        string          // 신뢰 프록시가 쓰는 클라이언트 헤더 (ProxyHeader* 중 하나, 다른 헤더는 무시)
+	Sessions           *SessionManager // c.Session() 용 (nil 이면 사용 안함)
+	Policy             *Policy         // 라우트 권한 검사 (nil 이면 DefaultPolicy)
 }
 
 // 앱 생성자
 func NewApp() *App {
 
 	app := &App{
-		Initialize:      func() {},
-		Finalize:        func() {},
-		OnShutdownErr:   func(err error) {},
-		OnSignal:        make(map[os.Signal]func()),
-		OnUnknownSignal: func(sig os.Signal) {},
-		Conns:           map[string]*sql.DB{},
-		Router:          NewRouter(),
-		Logger:          DefaultLogger,
+		Initialize:         func() {},
+		Finalize:           func() {},
+		OnShutdownErr:      func(err error) {},
+		OnSignal:           make(map[os.Signal]func()),
+		OnUnknownSignal:    func(sig os.Signal) {},
+		Conns:              map[string]*sql.DB{},
+		Router:             NewRouter(),
+		Logger:             DefaultLogger,
+		ProblemBaseURI:     "/problems/",
+		MaxBodySize:        32 << 20,
+		MaxDecompressRatio: 100,
+		ProxyHeader:        ProxyHeaderXForwardedFor,
 	}
 	app.Server = &http.Server{
 		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -53,7 +68,11 @@ func NewApp() *App {
 func (a *App) Run(Addr string, shutdownTimeout int) {
 	a.Server.Addr = Addr
 	a.Initialize()
-	a.Router.CreateIndexFiles()
+	if a.Templates != nil {
+		if err := a.Templates.Load(); err != nil {
+			panic(err)
+		}
+	}
 
 	a.Logger.Info("LogLevel", a.Logger.GetLevel())
 	a.Logger.Info("Timezone", a.Logger.GetTimezone().String())
@@ -152,10 +171,68 @@ type AppError struct {
 	Data map[string]any // 메시지 조립용 데이터
 }
 
+// 미리 정의된 에러 코드
+const (
+	CodeNotFound         = "NotFound"
+	CodeUnauthorized     = "Unauthorized"
+	CodeForbidden        = "Forbidden"
+	CodeConflict         = "Conflict"
+	CodeInvalidParameter = "InvalidParameter"
+	CodeTimeout          = "Timeout"
+)
+
+/*
+errors.Is 비교용 센티널. 코드만 같으면 일치로 판단함
+if errors.Is(err, x.ErrNotFound) { ... }
+*/
+var (
+	ErrNotFound         = &AppError{Code: CodeNotFound}
+	ErrUnauthorized     = &AppError{Code: CodeUnauthorized}
+	ErrForbidden        = &AppError{Code: CodeForbidden}
+	ErrConflict         = &AppError{Code: CodeConflict}
+	ErrInvalidParameter = &AppError{Code: CodeInvalidParameter}
+	ErrTimeout          = &AppError{Code: CodeTimeout}
+)
+
 func (e *AppError) String() string {
 	return fmt.Sprint(e.Code, " ", e.Src, " ", e.Err, " ", e.Data)
 }
 
+// error 인터페이스 구현
+func (e *AppError) Error() string {
+	if e.Err == nil {
+		return e.Code
+	}
+	// 감싼 AppError 가 이미 코드를 출력하므로 중복 방지
+	var inner *AppError
+	if errors.As(e.Err, &inner) {
+		return e.Err.Error()
+	}
+	return e.Code + ": " + e.Err.Error()
+}
+
+// errors.Is/As 가 원본 에러까지 따라갈 수 있게
+func (e *AppError) Unwrap() error {
+	return e.Err
+}
+
+// 같은 코드의 AppError 면 일치
+func (e *AppError) Is(target error) bool {
+	t, ok := target.(*AppError)
+	return ok && t.Code == e.Code
+}
+
+// 코드는 유지하고 설명을 덧붙인 새 AppError 반환
+func (e *AppError) Wrap(msg string) *AppError {
+	_, file, line, _ := runtime.Caller(1)
+	return &AppError{
+		Code: e.Code,
+		Src:  fmt.Sprintf("(%s:%d)", filepath.Base(file), line),
+		Err:  fmt.Errorf("%s: %w", msg, e),
+		Data: e.Data,
+	}
+}
+
 // Panic 메서드
 func (e *AppError) Panic() {
 	panic(e)
@@ -171,3 +248,52 @@ func NewAppError(code string, err error, data map[string]any) *AppError {
 		Data: data,
 	}
 }
+
+/*
+임의의 에러에 설명을 덧붙여 AppError 로 감싸기.
+체인 안에 AppError 가 있으면 그 코드를 유지하고 Data 는 병합, 없으면 RuntimeError
+*/
+func WrapAppError(err error, msg string, data map[string]any) *AppError {
+	_, file, line, _ := runtime.Caller(1)
+
+	code := "RuntimeError"
+	merged := map[string]any{}
+	var inner *AppError
+	if errors.As(err, &inner) {
+		code = inner.Code
+		for k, v := range inner.Data {
+			merged[k] = v
+		}
+	}
+	for k, v := range data {
+		merged[k] = v
+	}
+	if len(merged) == 0 {
+		merged = nil
+	}
+
+	return &AppError{
+		Code: code,
+		Src:  fmt.Sprintf("(%s:%d)", filepath.Base(file), line),
+		Err:  fmt.Errorf("%s: %w", msg, err),
+		Data: merged,
+	}
+}
+
+// 에러를 AppError 로 변환. 이미 AppError 가 체인에 있으면 그대로 사용
+// 본문 크기 초과(http.MaxBytesError, 압축폭탄)는 PayloadTooLarge
+func AsAppError(err error) *AppError {
+	var appErr *AppError
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	if appErr = bodyLimitError(err); appErr != nil {
+		return appErr
+	}
+	_, file, line, _ := runtime.Caller(1)
+	return &AppError{
+		Code: "RuntimeError",
+		Src:  fmt.Sprintf("(%s:%d)", filepath.Base(file), line),
+		Err:  err,
+	}
+}
@@ -0,0 +1,91 @@
+package x
+
+import (
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestAppError(t *testing.T) {
+	base := errors.New("no rows")
+	nf := NewAppError(CodeNotFound, base, map[string]any{"ID": 1})
+
+	if got := nf.Error(); got != "NotFound: no rows" {
+		t.Errorf("Error() = %q", got)
+	}
+	if got := (&AppError{Code: CodeConflict}).Error(); got != "Conflict" {
+		t.Errorf("Error() without Err = %q", got)
+	}
+	if !errors.Is(nf, base) || !errors.Is(nf, ErrNotFound) || errors.Is(nf, ErrConflict) {
+		t.Error("errors.Is through AppError")
+	}
+	if errors.Unwrap(nf) != base {
+		t.Error("Unwrap")
+	}
+
+	w := nf.Wrap("load user")
+	if w.Code != CodeNotFound || !errors.Is(w, base) || w.Data["ID"] != 1 {
+		t.Errorf("Wrap = %v", w)
+	}
+	if got := w.Error(); got != "load user: NotFound: no rows" {
+		t.Errorf("wrapped Error() = %q", got)
+	}
+	if !strings.HasPrefix(w.Src, "(app_test.go:") {
+		t.Errorf("Wrap Src = %q", w.Src)
+	}
+
+	// 체인 안의 AppError 코드 유지, Data 병합
+	wa := WrapAppError(fmt.Errorf("repo: %w", nf), "handler", map[string]any{"User": "u1"})
+	if wa.Code != CodeNotFound || wa.Data["ID"] != 1 || wa.Data["User"] != "u1" {
+		t.Errorf("WrapAppError = %v", wa)
+	}
+	if wa := WrapAppError(base, "query", nil); wa.Code != "RuntimeError" || wa.Data != nil || !errors.Is(wa, base) {
+		t.Errorf("WrapAppError(plain) = %v", wa)
+	}
+}
+
+type countingReporter struct {
+	mu    sync.Mutex
+	codes []string
+}
+
+func (r *countingReporter) Report(er *ErrorReport) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.codes = append(r.codes, er.AppError.Code)
+}
+
+func TestErrorHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		code     string
+		reported bool
+	}{
+		{"nil", nil, "OK", false},
+		{"plain error", errors.New("db down"), "RuntimeError", true},
+		{"wrapped runtime error", WrapAppError(errors.New("db down"), "query", nil), "RuntimeError", true},
+		{"app error", NewAppError(CodeNotFound, nil, nil), CodeNotFound, false},
+		{"wrapped app error", fmt.Errorf("ctx: %w", ErrForbidden), CodeForbidden, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewApp()
+			rep := &countingReporter{}
+			a.Reporter = rep
+			a.Router.AddRoute(a, "GET", "/h", ReplyJSON, E(func(c *Context) error { return tt.err }))
+
+			w := httptest.NewRecorder()
+			a.Server.Handler.ServeHTTP(w, httptest.NewRequest("GET", "/h", nil))
+			if body := w.Body.String(); !strings.Contains(body, `"Code":"`+tt.code+`"`) {
+				t.Fatalf("body %q, want code %s", body, tt.code)
+			}
+			if got := len(rep.codes) == 1; got != tt.reported {
+				t.Fatalf("reported %v, want %v", rep.codes, tt.reported)
+			}
+		})
+	}
+}
@@ -0,0 +1,267 @@
+package x
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// 역할/권한 기반 인가
+// /////////////////////////////////////////////////////////////////////////////
+
+// 인가 대상 (사용자 또는 서비스)
+type Principal struct {
+	ID          string
+	Roles       []string
+	Permissions []string
+}
+
+/*
+라우트에 필요한 권한 지정. Permissions 는 모두, Roles 는 하나 이상 만족해야 함
+
+	a.Router.AddRoute(a, "POST", "/orders", x.ReplyJSON, CreateOrder).Authenticate(v.Handler).Require("orders:write")
+	a.Router.AddRoute(a, "GET", "/admin/users", x.ReplyJSON, Users).Authenticate(v.Handler).RequireRole("admin")
+
+검사는 App.Policy 로 라우트 핸들러보다 먼저 수행됨
+인증은 전역 전처리기 또는 Authenticate 로 지정한 핸들러에서 할것 (라우트 핸들러는 검사 이후에 실행됨)
+*/
+func (r *Route) Require(perms ...string) *Route {
+	r.Permissions = append(r.Permissions, perms...)
+	return r
+}
+
+func (r *Route) RequireRole(roles ...string) *Route {
+	r.Roles = append(r.Roles, roles...)
+	return r
+}
+
+// 권한 검사 전에 실행할 인증 핸들러 (JWTVerifier.Handler, HMACAuth.Handler 등)
+func (r *Route) Authenticate(hs ...HandlerFunc) *Route {
+	for _, h := range hs {
+		r.Authenticators = append(r.Authenticators, h)
+		r.AuthenticatorNames = append(r.AuthenticatorNames, runtime.FuncForPC(reflect.ValueOf(h).Pointer()).Name())
+	}
+	return r
+}
+
+func (r *Route) restricted() bool {
+	return len(r.Roles) > 0 || len(r.Permissions) > 0
+}
+
+/*
+권한 평가기
+RolePermissions 로 역할에 권한을 묶을 수 있고, 권한은 "orders:*" 또는 "*" 와일드카드 허용
+
+	a.Policy = x.NewPolicy()
+	a.Policy.RolePermissions = map[string][]string{
+		"admin":  {"*"},
+		"editor": {"posts:*", "comments:read"},
+	}
+*/
+type Policy struct {
+	RolePermissions map[string][]string
+	Resolve         func(c *Context) *Principal // nil 이면 c.Principal, 없으면 c.Claims 에서 생성
+}
+
+var DefaultPolicy = NewPolicy()
+
+func NewPolicy() *Policy {
+	return &Policy{RolePermissions: map[string][]string{}}
+}
+
+func (c *Context) policy() *Policy {
+	if c.App.Policy != nil {
+		return c.App.Policy
+	}
+	return DefaultPolicy
+}
+
+/*
+JWT 클레임에서 Principal 생성
+  - sub → ID
+  - roles → Roles (배열 또는 공백 구분 문자열)
+  - permissions, scope, scp → Permissions
+*/
+func PrincipalFromClaims(cl JWTClaims) *Principal {
+	if cl == nil {
+		return nil
+	}
+	p := &Principal{ID: cl.Subject(), Roles: claimList(cl["roles"])}
+	for _, name := range []string{"permissions", "scope", "scp"} {
+		p.Permissions = append(p.Permissions, claimList(cl[name])...)
+	}
+	return p
+}
+
+func claimList(v any) []string {
+	switch v := v.(type) {
+	case string:
+		return strings.Fields(v)
+	case []any:
+		out := make([]string, 0, len(v))
+		for _, e := range v {
+			if s, ok := e.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	case []string:
+		return v
+	}
+	return nil
+}
+
+func (p *Policy) principal(c *Context) *Principal {
+	if p.Resolve != nil {
+		return p.Resolve(c)
+	}
+	if c.Principal != nil {
+		return c.Principal
+	}
+	return PrincipalFromClaims(c.Claims)
+}
+
+// "orders:*" 는 "orders:read", "orders:items:write" 와 일치
+func matchPermission(granted, want string) bool {
+	if granted == "*" || granted == want {
+		return true
+	}
+	prefix, ok := strings.CutSuffix(granted, "*")
+	return ok && strings.HasPrefix(want, prefix)
+}
+
+func (p *Policy) HasRole(pr *Principal, role string) bool {
+	return pr != nil && slices.Contains(pr.Roles, role)
+}
+
+// 직접 부여된 권한과 역할로 받은 권한 모두 확인
+func (p *Policy) Permits(pr *Principal, perm string) bool {
+	if pr == nil {
+		return false
+	}
+	for _, g := range pr.Permissions {
+		if matchPermission(g, perm) {
+			return true
+		}
+	}
+	for _, role := range pr.Roles {
+		for _, g := range p.RolePermissions[role] {
+			if matchPermission(g, perm) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// 라우트 요구사항 평가. 통과하지 못하면 Unauthorized 또는 Forbidden AppError
+func (p *Policy) Authorize(pr *Principal, r *Route) *AppError {
+	if r == nil || !r.restricted() {
+		return nil
+	}
+	if pr == nil {
+		return NewAppError(CodeUnauthorized, errors.New("authentication required"), nil)
+	}
+
+	if len(r.Roles) > 0 && !slices.ContainsFunc(r.Roles, func(role string) bool { return p.HasRole(pr, role) }) {
+		return NewAppError(CodeForbidden,
+			fmt.Errorf("principal %q lacks role (one of %v)", pr.ID, r.Roles),
+			map[string]any{"Reason": "role"})
+	}
+
+	var missing []string
+	for _, perm := range r.Permissions {
+		if !p.Permits(pr, perm) {
+			missing = append(missing, perm)
+		}
+	}
+	if len(missing) > 0 {
+		return NewAppError(CodeForbidden,
+			fmt.Errorf("principal %q lacks permissions %v", pr.ID, missing),
+			map[string]any{"Reason": "permission"})
+	}
+	return nil
+}
+
+// 현재 라우트의 요구사항 검사. 라우터가 라우트 핸들러 전에 자동으로 호출함
+func (p *Policy) Handler(c *Context) {
+	if c.Route == nil {
+		// 전처리기 단계에서는 라우트가 정해지지 않음. 라우터가 나중에 검사
+		return
+	}
+	pr := p.principal(c)
+	if err := p.Authorize(pr, c.Route); err != nil {
+		err.Panic()
+	}
+	c.Principal = pr
+	c.authorized = true
+}
+
+// 핸들러 안에서 조건부로 검사할 때
+func (c *Context) Can(perm string) bool {
+	p := c.policy()
+	return p.Permits(p.principal(c), perm)
+}
+
+func (c *Context) HasRole(role string) bool {
+	p := c.policy()
+	return p.HasRole(p.principal(c), role)
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// 감사용 라우트 목록
+// /////////////////////////////////////////////////////////////////////////////
+
+type RouteInfo struct {
+	Method         string
+	Path           string
+	Roles          []string
+	Permissions    []string
+	Authenticators []string
+	Handlers       []string
+}
+
+// 등록된 라우트 목록 (경로, 메서드 순)
+func (r *Router) Routes() []RouteInfo {
+	var out []RouteInfo
+	var walk func(n *node)
+	walk = func(n *node) {
+		if n.route != nil {
+			rt := n.route
+			out = append(out, RouteInfo{
+				Method:         rt.Method,
+				Path:           rt.Path,
+				Roles:          rt.Roles,
+				Permissions:    rt.Permissions,
+				Authenticators: rt.AuthenticatorNames,
+				Handlers:       rt.HandlerNames,
+			})
+		}
+		for _, ch := range n.children {
+			walk(ch)
+		}
+	}
+	for _, root := range r.trees {
+		walk(root)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Path != out[j].Path {
+			return out[i].Path < out[j].Path
+		}
+		return out[i].Method < out[j].Method
+	})
+	return out
+}
+
+/*
+라우트와 필요 권한 목록 핸들러. 보호해서 등록할것
+a.Router.AddRoute(a, "GET", "/admin/routes", x.ReplyJSON, a.Router.RoutesHandler).Authenticate(v.Handler).RequireRole("admin")
+*/
+func (r *Router) RoutesHandler(c *Context) {
+	c.Response.Data = r.Routes()
+}
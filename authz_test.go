@@ -0,0 +1,92 @@
+package x
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRoutePolicy(t *testing.T) {
+	a := NewApp()
+	a.Policy = NewPolicy()
+	a.Policy.RolePermissions = map[string][]string{"editor": {"posts:*"}}
+
+	// X-Test-Role 헤더로 역할을 지정하는 인증 핸들러
+	auth := func(c *Context) {
+		if role := c.Req.Header.Get("X-Test-Role"); role != "" {
+			c.Principal = &Principal{ID: "u1", Roles: []string{role}}
+		}
+	}
+	ran := false
+	mark := func(c *Context) {
+		ran = true
+		c.Response.Data = "ran"
+	}
+
+	a.Router.AddRoute(a, "GET", "/empty", ReplyJSON).RequireRole("admin")
+	a.Router.AddRoute(a, "GET", "/admin", ReplyJSON, mark).Authenticate(auth).RequireRole("admin")
+	a.Router.AddRoute(a, "GET", "/posts", ReplyJSON, mark).Authenticate(auth).Require("posts:write")
+	a.Router.AddRoute(a, "GET", "/open", ReplyJSON, mark)
+
+	tests := []struct {
+		name string
+		path string
+		role string
+		code string
+		ran  bool
+	}{
+		{"no handlers", "/empty", "", `"Code":"Unauthorized"`, false},
+		{"unauthenticated", "/admin", "", `"Code":"Unauthorized"`, false},
+		{"wrong role", "/admin", "editor", `"Code":"Forbidden"`, false},
+		{"role", "/admin", "admin", `"Code":"OK"`, true},
+		{"wildcard permission", "/posts", "editor", `"Code":"OK"`, true},
+		{"missing permission", "/posts", "viewer", `"Code":"Forbidden"`, false},
+		{"unrestricted", "/open", "", `"Code":"OK"`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ran = false
+			r := httptest.NewRequest("GET", tt.path, nil)
+			if tt.role != "" {
+				r.Header.Set("X-Test-Role", tt.role)
+			}
+			w := httptest.NewRecorder()
+			a.Server.Handler.ServeHTTP(w, r)
+			if body := w.Body.String(); !strings.Contains(body, tt.code) {
+				t.Fatalf("body %q, want %s", body, tt.code)
+			}
+			if ran != tt.ran {
+				t.Fatalf("handler ran = %v, want %v", ran, tt.ran)
+			}
+		})
+	}
+}
+
+func TestMatchPermission(t *testing.T) {
+	tests := []struct {
+		granted, want string
+		ok            bool
+	}{
+		{"*", "orders:read", true},
+		{"orders:read", "orders:read", true},
+		{"orders:*", "orders:items:write", true},
+		{"orders:*", "users:read", false},
+		{"orders:read", "orders:write", false},
+	}
+	for _, tt := range tests {
+		if got := matchPermission(tt.granted, tt.want); got != tt.ok {
+			t.Errorf("matchPermission(%q, %q) = %v", tt.granted, tt.want, got)
+		}
+	}
+}
+
+func TestRoutes(t *testing.T) {
+	a := NewApp()
+	a.Router.AddRoute(a, "POST", "/b", ReplyJSON).Require("b:write")
+	a.Router.AddRoute(a, "GET", "/a", ReplyJSON).RequireRole("admin")
+
+	routes := a.Router.Routes()
+	if len(routes) != 2 || routes[0].Path != "/a" || routes[1].Permissions[0] != "b:write" {
+		t.Fatalf("routes = %+v", routes)
+	}
+}
@@ -0,0 +1,151 @@
+package x
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// 요청 본문: 크기 제한, 압축 해제
+// /////////////////////////////////////////////////////////////////////////////
+
+const (
+	CodePayloadTooLarge      = "PayloadTooLarge"
+	CodeUnsupportedMediaType = "UnsupportedMediaType"
+)
+
+func init() {
+	CodeStatus[CodePayloadTooLarge] = http.StatusRequestEntityTooLarge
+	CodeStatus[CodeUnsupportedMediaType] = http.StatusUnsupportedMediaType
+}
+
+// 압축 해제 비율 초과 (압축폭탄)
+var ErrDecompressionBomb = errors.New("decompression ratio exceeded")
+
+// 라우트 설정이 있으면 라우트, 없으면 앱 전역 설정
+func (a *App) bodyLimit(r *Route) int64 {
+	if r != nil && r.MaxBodySize != 0 {
+		return r.MaxBodySize
+	}
+	return a.MaxBodySize
+}
+
+/*
+핸들러가 읽을 본문 준비. 라우팅 직후 전처리기보다 먼저 호출됨
+  - Content-Length 가 제한을 넘으면 즉시 PayloadTooLarge
+  - Content-Encoding: gzip/deflate 는 투명하게 해제 (해제 후 크기에도 제한 적용)
+  - 제한을 넘겨 읽으면 http.MaxBytesError → Recover 에서 PayloadTooLarge 로 변환
+*/
+func (c *Context) prepareBody(route *Route) {
+	if c.Req.Body == nil || c.Req.Body == http.NoBody {
+		return
+	}
+	limit := c.App.bodyLimit(route)
+
+	if limit > 0 {
+		if c.Req.ContentLength > limit {
+			NewAppError(CodePayloadTooLarge, nil, map[string]any{"Limit": limit}).Panic()
+		}
+		c.Req.Body = http.MaxBytesReader(c.Res, c.Req.Body, limit)
+	}
+
+	if enc := strings.ToLower(strings.TrimSpace(c.Req.Header.Get("Content-Encoding"))); enc != "" && enc != "identity" {
+		c.decompressBody(enc, limit)
+	}
+
+	if c.App.Logger.GetLevel() == "DEBUG" {
+		c.CopyBody()
+	}
+}
+
+func (c *Context) decompressBody(enc string, limit int64) {
+	raw := &countingReader{r: c.Req.Body}
+
+	var zr io.ReadCloser
+	switch enc {
+	case "gzip", "x-gzip":
+		gz, err := gzip.NewReader(raw)
+		if err != nil {
+			NewAppError(CodeInvalidParameter, err, map[string]any{"Content-Encoding": enc}).Panic()
+		}
+		zr = gz
+	case "deflate":
+		// HTTP 의 deflate 는 zlib 포맷 (RFC 9110 8.4.1.2)
+		zl, err := zlib.NewReader(raw)
+		if err != nil {
+			NewAppError(CodeInvalidParameter, err, map[string]any{"Content-Encoding": enc}).Panic()
+		}
+		zr = zl
+	default:
+		NewAppError(CodeUnsupportedMediaType, fmt.Errorf("content-encoding %q", enc), nil).Panic()
+	}
+
+	var body io.ReadCloser = &ratioLimitReader{
+		r:     zr,
+		raw:   raw,
+		ratio: c.App.MaxDecompressRatio,
+		close: c.Req.Body.Close,
+	}
+	if limit > 0 {
+		body = http.MaxBytesReader(c.Res, body, limit)
+	}
+
+	c.Req.Body = body
+	c.Req.Header.Del("Content-Encoding")
+	c.Req.Header.Del("Content-Length")
+	c.Req.ContentLength = -1
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n += int64(n)
+	return n, err
+}
+
+// 해제된 크기가 원본 대비 ratio 배를 넘으면 ErrDecompressionBomb
+type ratioLimitReader struct {
+	r     io.ReadCloser
+	raw   *countingReader
+	ratio int
+	n     int64
+	close func() error
+}
+
+// 작은 본문은 비율이 크게 나와도 허용
+const ratioSlack = 64 * 1024
+
+func (rl *ratioLimitReader) Read(p []byte) (int, error) {
+	n, err := rl.r.Read(p)
+	rl.n += int64(n)
+	if rl.ratio > 0 && rl.n > ratioSlack && rl.n > rl.raw.n*int64(rl.ratio) {
+		return n, ErrDecompressionBomb
+	}
+	return n, err
+}
+
+func (rl *ratioLimitReader) Close() error {
+	rl.r.Close()
+	return rl.close()
+}
+
+// 본문 읽기 중 발생한 크기 초과 에러를 AppError 로 변환. 해당 없으면 nil
+func bodyLimitError(err error) *AppError {
+	var mbe *http.MaxBytesError
+	if errors.As(err, &mbe) {
+		return NewAppError(CodePayloadTooLarge, err, map[string]any{"Limit": mbe.Limit})
+	}
+	if errors.Is(err, ErrDecompressionBomb) {
+		return NewAppError(CodePayloadTooLarge, err, nil)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package x
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/zlib"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRouteBodyLimit(t *testing.T) {
+	a := NewApp()
+	a.MaxBodySize = 10
+	var preRoute *Route
+	a.Router.AddPreprocessors(func(c *Context) { preRoute = c.Route })
+	a.Router.AddRoute(a, "POST", "/small", ReplyJSON)
+	a.Router.AddRoute(a, "POST", "/large", ReplyJSON).MaxBodySize = 100
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/small", `"Code":"PayloadTooLarge"`},
+		{"/large", `"Code":"OK"`},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		a.Server.Handler.ServeHTTP(w, httptest.NewRequest("POST", tt.path, strings.NewReader(strings.Repeat("a", 50))))
+		if body := w.Body.String(); !strings.Contains(body, tt.want) {
+			t.Errorf("%s: body %q, want %s", tt.path, body, tt.want)
+		}
+	}
+	// 라우트는 전처리기 이후에 설정됨
+	if preRoute != nil {
+		t.Errorf("c.Route set before preprocessors: %v", preRoute.Path)
+	}
+}
+
+func TestDeflateRequestBody(t *testing.T) {
+	a := NewApp()
+	a.Router.AddRoute(a, "POST", "/echo", ReplyJSON, func(c *Context) {
+		b, err := io.ReadAll(c.Req.Body)
+		if err != nil {
+			panic(err)
+		}
+		c.Response.Data = string(b)
+	})
+
+	var zbuf bytes.Buffer
+	zw := zlib.NewWriter(&zbuf)
+	zw.Write([]byte("hello"))
+	zw.Close()
+	var fbuf bytes.Buffer
+	fw, _ := flate.NewWriter(&fbuf, flate.DefaultCompression)
+	fw.Write([]byte("hello"))
+	fw.Close()
+
+	tests := []struct {
+		name string
+		body []byte
+		want string
+	}{
+		{"zlib", zbuf.Bytes(), `"Data":"hello"`},
+		{"raw deflate", fbuf.Bytes(), `"Code":"InvalidParameter"`},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("POST", "/echo", bytes.NewReader(tt.body))
+		r.Header.Set("Content-Encoding", "deflate")
+		w := httptest.NewRecorder()
+		a.Server.Handler.ServeHTTP(w, r)
+		if body := w.Body.String(); !strings.Contains(body, tt.want) {
+			t.Errorf("%s: body %q, want %s", tt.name, body, tt.want)
+		}
+	}
+}
+
+func TestBodyLimitErrorHandler(t *testing.T) {
+	a := NewApp()
+	a.MaxBodySize = 10
+	a.Router.AddRoute(a, "POST", "/read", ReplyJSON, E(func(c *Context) error {
+		_, err := io.ReadAll(c.Req.Body)
+		return err
+	}))
+
+	// Content-Length 없이 보내서 읽는 도중에 제한에 걸리게 함
+	r := httptest.NewRequest("POST", "/read", io.MultiReader(strings.NewReader(strings.Repeat("a", 50))))
+	w := httptest.NewRecorder()
+	a.Server.Handler.ServeHTTP(w, r)
+	if body := w.Body.String(); !strings.Contains(body, `"Code":"PayloadTooLarge"`) {
+		t.Fatalf("body %q", body)
+	}
+}
@@ -0,0 +1,288 @@
+package x
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// 응답 압축
+// /////////////////////////////////////////////////////////////////////////////
+
+// 압축기. gzip.Writer, zlib.Writer, brotli.Writer 모두 만족함
+type CompressWriter interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+type compressor struct {
+	encoding string
+	pool     *sync.Pool
+}
+
+// 등록 순서가 곧 우선순위 (q 값이 같을 때)
+var compressors []compressor
+
+/*
+압축기 등록. 같은 인코딩이 있으면 교체. 기동 시에만 호출할것
+brotli 예: x.RegisterCompressor("br", func(w io.Writer) x.CompressWriter { return brotli.NewWriterLevel(w, 5) })
+br 을 gzip 보다 우선하려면 RegisterCompressor 뒤에 PreferCompressor("br") 호출
+*/
+func RegisterCompressor(encoding string, factory func(w io.Writer) CompressWriter) {
+	pool := &sync.Pool{New: func() any { return factory(io.Discard) }}
+	for i := range compressors {
+		if compressors[i].encoding == encoding {
+			compressors[i].pool = pool
+			return
+		}
+	}
+	compressors = append(compressors, compressor{encoding, pool})
+}
+
+// 해당 인코딩을 맨 앞으로
+func PreferCompressor(encoding string) {
+	for i, c := range compressors {
+		if c.encoding == encoding {
+			copy(compressors[1:i+1], compressors[:i])
+			compressors[0] = c
+			return
+		}
+	}
+}
+
+func init() {
+	RegisterCompressor("gzip", func(w io.Writer) CompressWriter {
+		gw, _ := gzip.NewWriterLevel(w, gzip.DefaultCompression)
+		return gw
+	})
+	// HTTP 의 deflate 는 zlib 포맷 (RFC 9110 8.4.1.2)
+	RegisterCompressor("deflate", func(w io.Writer) CompressWriter {
+		zw, _ := zlib.NewWriterLevel(w, zlib.DefaultCompression)
+		return zw
+	})
+}
+
+// Accept-Encoding 에서 가장 높은 q 값의 등록된 압축기 선택
+func negotiateEncoding(header string) *compressor {
+	q := map[string]float64{}
+	star := -1.0
+	for _, part := range strings.Split(header, ",") {
+		token, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		token = strings.ToLower(strings.TrimSpace(token))
+		if token == "" {
+			continue
+		}
+		v := 1.0
+		k, qs, _ := strings.Cut(strings.TrimSpace(params), "=")
+		if strings.TrimSpace(k) == "q" {
+			if f, err := strconv.ParseFloat(strings.TrimSpace(qs), 64); err == nil {
+				v = f
+			}
+		}
+		if token == "*" {
+			star = v
+		} else {
+			q[token] = v
+		}
+	}
+
+	var best *compressor
+	bestQ := 0.0
+	for i := range compressors {
+		v, ok := q[compressors[i].encoding]
+		if !ok {
+			v = star
+		}
+		if v > bestQ {
+			best, bestQ = &compressors[i], v
+		}
+	}
+	return best
+}
+
+// 이미 압축된 콘텐츠 타입 (접두사 일치)
+var DefaultCompressSkipTypes = []string{
+	"image/", "video/", "audio/", "font/woff",
+	"application/zip", "application/gzip", "application/x-gzip",
+	"application/x-7z-compressed", "application/x-rar-compressed",
+	"application/pdf", "application/octet-stream", "application/msgpack",
+}
+
+// 이보다 작은 응답은 압축해도 이득이 거의 없음
+const DefaultCompressMinSize = 1024
+
+type CompressOptions struct {
+	MinSize   int      // 이보다 작은 응답은 압축 안함 (0 이면 DefaultCompressMinSize, 음수면 모두 압축)
+	SkipTypes []string // 압축하지 않을 Content-Type 접두사
+}
+
+/*
+응답 압축 전처리기. 정적 파일과 Reply 모두 적용되며
+이미 Content-Encoding 이 있는 응답(사전압축 파일 등)과 Range 응답은 건너뜀
+a.Router.AddPreprocessors(x.Compress(x.CompressOptions{}))
+*/
+func Compress(opts CompressOptions) HandlerFunc {
+	if opts.MinSize == 0 {
+		opts.MinSize = DefaultCompressMinSize
+	}
+	if opts.SkipTypes == nil {
+		opts.SkipTypes = DefaultCompressSkipTypes
+	}
+	return func(c *Context) {
+		addVary(c.Res.Header(), "Accept-Encoding")
+		if c.Req.Method == http.MethodHead {
+			return
+		}
+		comp := negotiateEncoding(c.Req.Header.Get("Accept-Encoding"))
+		if comp == nil {
+			return
+		}
+		cw := &compressResponseWriter{
+			ResponseWriter: c.Res,
+			opts:           &opts,
+			comp:           comp,
+		}
+		c.Res = cw
+		c.Defer(cw.Close)
+	}
+}
+
+type compressResponseWriter struct {
+	http.ResponseWriter
+	opts *CompressOptions
+	comp *compressor
+
+	buf      []byte
+	status   int
+	decided  bool
+	compress bool
+	cw       CompressWriter
+}
+
+func (w *compressResponseWriter) WriteHeader(code int) {
+	if w.decided {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if code >= 100 && code < 200 {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	w.status = code
+	// 본문이 없는 응답은 바로 결정
+	if code == http.StatusNoContent || code == http.StatusNotModified {
+		w.decide(false)
+	}
+}
+
+func (w *compressResponseWriter) Write(p []byte) (int, error) {
+	if !w.decided {
+		w.buf = append(w.buf, p...)
+		if len(w.buf) < w.opts.MinSize {
+			return len(p), nil
+		}
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	if w.compress {
+		return w.cw.Write(p)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// 압축 여부 결정 후 헤더와 버퍼를 내보냄
+func (w *compressResponseWriter) decide(enough bool) error {
+	w.decided = true
+	h := w.Header()
+
+	if h.Get("Content-Type") == "" && len(w.buf) > 0 {
+		h.Set("Content-Type", http.DetectContentType(w.buf))
+	}
+
+	w.compress = enough &&
+		h.Get("Content-Encoding") == "" &&
+		h.Get("Content-Range") == "" &&
+		w.status != http.StatusPartialContent &&
+		!w.skipType(h.Get("Content-Type"))
+
+	if w.compress {
+		h.Set("Content-Encoding", w.comp.encoding)
+		h.Del("Content-Length")
+		h.Del("Accept-Ranges")
+		// 바이트가 달라지므로 강한 ETag 는 약한 ETag 로
+		if et := h.Get("ETag"); strings.HasPrefix(et, `"`) {
+			h.Set("ETag", "W/"+et)
+		}
+		w.cw = w.comp.pool.Get().(CompressWriter)
+		w.cw.Reset(w.ResponseWriter)
+	}
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	var err error
+	if w.compress {
+		_, err = w.cw.Write(buf)
+	} else {
+		_, err = w.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+func (w *compressResponseWriter) skipType(ct string) bool {
+	ct = strings.ToLower(ct)
+	for _, t := range w.opts.SkipTypes {
+		if strings.HasPrefix(ct, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// 스트리밍 응답: 작은 버퍼라도 바로 압축 시작
+func (w *compressResponseWriter) Flush() {
+	if !w.decided {
+		w.decide(true)
+	}
+	if w.compress {
+		w.cw.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// 응답 완료 후 Recover 에서 호출됨
+func (w *compressResponseWriter) Close() {
+	if !w.decided {
+		if w.status == 0 && len(w.buf) == 0 {
+			return
+		}
+		w.decide(false)
+	}
+	if w.compress {
+		w.cw.Close()
+		w.cw.Reset(io.Discard)
+		w.comp.pool.Put(w.cw)
+		w.cw = nil
+		w.compress = false
+	}
+}
+
+// http.ResponseController 지원
+func (w *compressResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
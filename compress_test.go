@@ -0,0 +1,67 @@
+package x
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCompress(t *testing.T) {
+	a := NewApp()
+	a.Router.AddPreprocessors(Compress(CompressOptions{MinSize: 10}))
+	a.Router.AddRoute(a, "GET", "/data", ReplyJSON, func(c *Context) {
+		c.Response.Data = strings.Repeat("x", 100)
+	})
+
+	tests := []struct {
+		encoding string
+		reader   func(io.Reader) (io.Reader, error)
+	}{
+		{"gzip", func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) }},
+		{"deflate", func(r io.Reader) (io.Reader, error) { return zlib.NewReader(r) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.encoding, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/data", nil)
+			r.Header.Set("Accept-Encoding", tt.encoding)
+			w := httptest.NewRecorder()
+			a.Server.Handler.ServeHTTP(w, r)
+			if got := w.Header().Get("Content-Encoding"); got != tt.encoding {
+				t.Fatalf("Content-Encoding = %q", got)
+			}
+			zr, err := tt.reader(w.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := io.ReadAll(zr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(b), strings.Repeat("x", 100)) {
+				t.Fatalf("body %q", b)
+			}
+		})
+	}
+}
+
+func TestCompressDefaultMinSize(t *testing.T) {
+	a := NewApp()
+	a.Router.AddPreprocessors(Compress(CompressOptions{}))
+	a.Router.AddRoute(a, "GET", "/small", ReplyJSON, func(c *Context) {
+		c.Response.Data = "tiny"
+	})
+	a.Router.AddRoute(a, "GET", "/large", ReplyJSON, func(c *Context) {
+		c.Response.Data = strings.Repeat("x", DefaultCompressMinSize)
+	})
+
+	for path, want := range map[string]string{"/small": "", "/large": "gzip"} {
+		r := httptest.NewRequest("GET", path, nil)
+		r.Header.Set("Accept-Encoding", "gzip")
+		if got := do(a, r).Header().Get("Content-Encoding"); got != want {
+			t.Errorf("%s: Content-Encoding = %q, want %q", path, got, want)
+		}
+	}
+}
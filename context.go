@@ -2,10 +2,12 @@ package x
 
 import (
 	"bytes"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"html"
 	"io"
-	"net"
 	"net/http"
 	"runtime/debug"
 	"strings"
@@ -25,19 +27,30 @@ func (b TextBytes) String() string {
 
 // Context : 요청/응답을 담는 컨텍스트
 type Context struct {
-	App       *App
-	Req       *http.Request
-	Res       http.ResponseWriter
-	AppError  *AppError
-	RouteType string
-	Store     map[string]any //핸들러 체인들이 자유롭게 데이터 담을 수 있게
-	ReqID     string
-	ReqTime   time.Time
-	ReqBody   TextBytes
-	RemoteIP  string
-	Route     *Route
-	Executed  []string
-	Response  struct {
+	App        *App
+	Req        *http.Request
+	Res        http.ResponseWriter
+	AppError   *AppError
+	RouteType  string
+	Store      map[string]any //핸들러 체인들이 자유롭게 데이터 담을 수 있게
+	ReqID      string
+	ReqTime    time.Time
+	ReqBody    TextBytes
+	RemoteIP   string
+	Scheme     string // http/https (신뢰 프록시의 Forwarded, X-Forwarded-Proto 반영)
+	Host       string // 신뢰 프록시의 Forwarded, X-Forwarded-Host 반영
+	Route      *Route
+	Executed   []string
+	View       string     // ReplyHTML 이 렌더링할 템플릿 이름
+	Claims     JWTClaims  // JWTVerifier 가 검증한 클레임
+	Principal  *Principal // 인가 대상. 인증 핸들러가 설정하거나 Policy 가 Claims 에서 만듦
+	deferred   []func()
+	onReply    []func()
+	session    *Session
+	authorized bool
+	txs        map[string]*sql.Tx
+	txOrder    []string
+	Response   struct {
 		Code    string
 		Message string
 		Data    any
@@ -48,18 +61,14 @@ type Context struct {
 func NewContext(a *App, w http.ResponseWriter, r *http.Request) *Context {
 	now := time.Now()
 	c := &Context{
-		App:      a,
-		Req:      r,
-		Res:      w,
-		Store:    map[string]any{},
-		ReqID:    util.EncodeToBase62(uint64(now.UnixNano())),
-		ReqTime:  now,
-		RemoteIP: getClientIP(r),
+		App:     a,
+		Req:     r,
+		Res:     w,
+		Store:   map[string]any{},
+		ReqID:   util.EncodeToBase62(uint64(now.UnixNano())),
+		ReqTime: now,
 	}
-	if a.Logger.GetLevel() == "DEBUG" {
-		c.CopyBody()
-	}
-
+	c.resolveClient()
 	return c
 }
 
@@ -79,58 +88,43 @@ func (c *Context) CopyBody() {
 	bodyBytes, err := io.ReadAll(io.LimitReader(c.Req.Body, 1024*1024))
 	if err != nil {
 		c.ReqBody = nil
+		if appErr := bodyLimitError(err); appErr != nil {
+			appErr.Panic()
+		}
 		return
 	}
 
-	// Body 복원
-	c.Req.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
+	// Body 복원. 1MB 를 넘는 나머지는 원본에서 이어서 읽음
+	c.Req.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(bodyBytes), c.Req.Body), c.Req.Body}
 	c.ReqBody = bodyBytes
 }
 
-func getClientIP(r *http.Request) string {
-	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
-		ips := strings.Split(xff, ",")
-		if len(ips) > 0 {
-			ipStr := strings.TrimSpace(ips[0])
-			if net.ParseIP(ipStr) != nil {
-				return ipStr
-			}
-		}
-	}
-
-	if xRealIP := r.Header.Get("X-Real-IP"); xRealIP != "" {
-		if net.ParseIP(xRealIP) != nil {
-			return xRealIP
-		}
-	}
-
-	if cfConnectingIP := r.Header.Get("CF-Connecting-IP"); cfConnectingIP != "" {
-		if net.ParseIP(cfConnectingIP) != nil {
-			return cfConnectingIP
-		}
-	}
-
-	ip, _, err := net.SplitHostPort(r.RemoteAddr)
-	if err != nil {
-		return r.RemoteAddr
-	}
-	return ip
-}
-
 var noErr = NewAppError("OK", nil, nil)
 
 func (c *Context) Recover() {
 	if rec := recover(); rec != nil {
 		var appErr *AppError
 		switch e := rec.(type) {
-		case *AppError:
-			appErr = e
 		case error:
+			// AppError(또는 감싼 AppError)는 그대로, 본문 크기 초과는 PayloadTooLarge
+			if errors.As(e, &appErr) {
+				break
+			}
+			if appErr = bodyLimitError(e); appErr != nil {
+				break
+			}
 			appErr = NewAppError("RuntimeError", e, nil)
-			c.App.Logger.Error(fmt.Sprintf("%s", debug.Stack()))
 		default:
 			appErr = NewAppError("RuntimeError", fmt.Errorf("%v", rec), nil)
-			c.App.Logger.Error(fmt.Sprintf("%s", debug.Stack()))
+		}
+		// 내부 에러는 AppError 로 감싸서 던졌어도 스택을 남기고 보고
+		if appErr.internal() {
+			stack := debug.Stack()
+			c.App.Logger.Error(fmt.Sprintf("%s", stack))
+			c.reportError(appErr, stack)
 		}
 		c.AppError = appErr
 	} else {
@@ -142,9 +136,14 @@ func (c *Context) Recover() {
 
 	//정적파일 서빙은 ServeFile 함수가 직접 응답함.
 	c.App.Logger.Debug(c.Route)
+	c.runBeforeReply()
 	if c.Route != nil {
 		c.Route.Reply(c)
+	} else if c.AppError.Code != "OK" {
+		// 정적파일 경로 또는 전처리기 단계에서 실패한 경우
+		ReplyJSON(c)
 	}
+	c.runDeferred()
 
 	//디버그 로그 (운영 성능 영향 제로)
 	c.App.Logger.Debug(
@@ -160,6 +159,17 @@ func (c *Context) Recover() {
 
 func ReplyJSON(c *Context) {
 	c.App.Logger.Debug("ReplyJSON")
+
+	// 에러 응답이고 클라이언트가 problem+json 을 원하면 RFC 7807 로 응답
+	if c.Response.Code != "OK" && acceptsProblem(c.Req) {
+		ReplyProblem(c)
+		return
+	}
+	replyEnvelope(c)
+}
+
+// {Code, Message, Data, Elapsed} 봉투 응답
+func replyEnvelope(c *Context) {
 	c.Res.Header().Set("Content-Type", "application/json; charset=utf-8")
 
 	c.Response.Message = "메세지조립할것"
@@ -171,21 +181,68 @@ func ReplyJSON(c *Context) {
 func ReplyHTML(c *Context) {
 	c.App.Logger.Debug("ReplyHTML")
 	c.Res.Header().Set("Content-Type", "text/html; charset=utf-8")
+	t := c.App.Templates
 
 	if c.Response.Code == "OK" {
-		if html, ok := c.Response.Data.(string); ok {
+		if t != nil && c.View != "" {
+			if err := t.Render(c.Res, c.View, c.Response.Data); err != nil {
+				c.App.Logger.Error("ReplyHTML", c.View, err)
+				http.Error(c.Res, "template error", http.StatusInternalServerError)
+			}
+		} else if html, ok := c.Response.Data.(string); ok {
 			fmt.Fprint(c.Res, html)
 		} else {
 			//응답데이터가 html 텍스트가 아니므로 JSON 마샬 응답
 			ReplyJSON(c)
 		}
-	} else {
-		fmt.Fprintf(
-			c.Res,
-			"<html><body><h1>Error: %s</h1></body></html>",
-			c.Response.Code,
-		)
+		return
+	}
+
+	c.Res.WriteHeader(StatusOf(c.Response.Code))
+	if t != nil {
+		if name := t.errorPage(c.Response.Code); name != "" {
+			data := map[string]any{
+				"Code":  c.Response.Code,
+				"Error": c.AppError.publicMessage(),
+				"Data":  c.AppError.Data,
+				"ReqID": c.ReqID,
+			}
+			err := t.Render(c.Res, name, data)
+			if err == nil {
+				return
+			}
+			c.App.Logger.Error("ReplyHTML", name, err)
+		}
+	}
+	fmt.Fprintf(
+		c.Res,
+		"<html><body><h1>Error: %s</h1></body></html>",
+		html.EscapeString(c.Response.Code),
+	)
+}
+
+// 응답을 쓰기 직전에 실행할 함수 등록 (쿠키/헤더 설정용, 등록 순서대로 실행)
+func (c *Context) BeforeReply(f func()) {
+	c.onReply = append(c.onReply, f)
+}
+
+func (c *Context) runBeforeReply() {
+	for _, f := range c.onReply {
+		f()
+	}
+	c.onReply = nil
+}
+
+// 응답이 끝난 뒤 실행할 함수 등록 (나중에 등록한 것부터 실행)
+func (c *Context) Defer(f func()) {
+	c.deferred = append(c.deferred, f)
+}
+
+func (c *Context) runDeferred() {
+	for i := len(c.deferred) - 1; i >= 0; i-- {
+		c.deferred[i]()
 	}
+	c.deferred = nil
 }
 
 // 값 저장
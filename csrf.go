@@ -0,0 +1,249 @@
+package x
+
+import (
+	"crypto/hmac"
+	crand "crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// CSRF
+// /////////////////////////////////////////////////////////////////////////////
+
+type CSRFMode int
+
+const (
+	CSRFDoubleSubmit CSRFMode = iota // 서명된 토큰을 쿠키와 요청 양쪽에 담아 비교
+	CSRFSynchronizer                 // 토큰을 세션에 보관 (App.Sessions 필요)
+)
+
+const (
+	csrfStoreKey      = "csrf_token"
+	csrfFieldStoreKey = "csrf_field"
+)
+
+const DefaultCSRFFieldName = "csrf_token"
+
+type CSRF struct {
+	Key            []byte // 토큰 서명 키
+	Mode           CSRFMode
+	CookieName     string
+	HeaderName     string   // AJAX 요청이 토큰을 보낼 헤더
+	FieldName      string   // 폼 필드 이름
+	TrustedOrigins []string // 자기 자신 외에 허용할 Origin (예: "https://admin.example.com")
+
+	// 토큰을 묶을 사용자별 값. nil 이면 세션 ID (App.Sessions 필요)
+	// 세션이 없는 앱은 로그인 사용자 ID 등 요청마다 같은 값을 돌려주도록 지정
+	Subject func(c *Context) string
+}
+
+/*
+CSRF 전처리기 생성
+csrf := x.NewCSRF(secretKey)
+a.Router.AddPreprocessors(csrf.Handler)
+
+토큰 서명에 Subject(기본 세션 ID)가 들어가므로 다른 사용자의 토큰/쿠키를 심어도 통과하지 못함
+Subject 와 App.Sessions 가 모두 없으면 설정 에러로 요청을 처리하지 않음
+
+템플릿: 핸들러에서 c.Response.Data 에 c.CSRFField() 를 넣거나 {{csrfField .CSRF}} 사용
+FieldName 을 바꿨으면 템플릿 함수도 바꿀것: tpl.Funcs["csrfField"] = csrf.Field
+AJAX: <meta name="csrf-token" content="..."> 값을 X-CSRF-Token 헤더로 전송
+*/
+func NewCSRF(key []byte) *CSRF {
+	return &CSRF{
+		Key:        key,
+		Mode:       CSRFDoubleSubmit,
+		CookieName: "csrf",
+		HeaderName: "X-CSRF-Token",
+		FieldName:  DefaultCSRFFieldName,
+	}
+}
+
+// 토큰을 묶을 값이 없는 설정이면 에러. 세션 없이 c.Session() 을 부르면 모든 요청이 패닉으로 끝나므로 미리 검사
+func (cs *CSRF) check(a *App) error {
+	if a.Sessions != nil {
+		return nil
+	}
+	if cs.Mode == CSRFSynchronizer {
+		return errors.New("csrf: CSRFSynchronizer requires App.Sessions")
+	}
+	if cs.Subject == nil {
+		return errors.New("csrf: Subject or App.Sessions is required")
+	}
+	return nil
+}
+
+func (cs *CSRF) subject(c *Context) string {
+	if cs.Subject != nil {
+		return cs.Subject(c)
+	}
+	return c.Session().ID
+}
+
+func (cs *CSRF) sign(subject string, nonce []byte) []byte {
+	m := hmac.New(sha256.New, cs.Key)
+	m.Write([]byte("csrf|"))
+	m.Write([]byte(subject))
+	m.Write([]byte{0})
+	m.Write(nonce)
+	return m.Sum(nil)
+}
+
+func (cs *CSRF) newToken(subject string) string {
+	nonce := make([]byte, 32)
+	if _, err := crand.Read(nonce); err != nil {
+		panic(err)
+	}
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString(nonce) + "." + enc.EncodeToString(cs.sign(subject, nonce))
+}
+
+// 서버 키로 이 사용자(subject)에게 서명된 토큰인지
+func (cs *CSRF) valid(token, subject string) bool {
+	n, s, ok := strings.Cut(token, ".")
+	if !ok {
+		return false
+	}
+	nonce, err1 := base64.RawURLEncoding.DecodeString(n)
+	sig, err2 := base64.RawURLEncoding.DecodeString(s)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	return hmac.Equal(sig, cs.sign(subject, nonce))
+}
+
+// 현재 요청에 발급된(또는 보관된) 토큰. 없으면 새로 발급
+func (cs *CSRF) token(c *Context) string {
+	subject := cs.subject(c)
+	if cs.Mode == CSRFSynchronizer {
+		s := c.Session()
+		if t, ok := s.Get(csrfStoreKey).(string); ok && cs.valid(t, subject) {
+			return t
+		}
+		t := cs.newToken(subject)
+		s.Set(csrfStoreKey, t)
+		return t
+	}
+
+	if ck, err := c.Req.Cookie(cs.CookieName); err == nil && cs.valid(ck.Value, subject) {
+		return ck.Value
+	}
+	t := cs.newToken(subject)
+	http.SetCookie(c.Res, &http.Cookie{
+		Name:     cs.CookieName,
+		Value:    t,
+		Path:     "/",
+		Secure:   c.Scheme == "https",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return t
+}
+
+func isSafeMethod(m string) bool {
+	return m == http.MethodGet || m == http.MethodHead || m == http.MethodOptions || m == http.MethodTrace
+}
+
+// Origin, 없으면 Referer 의 출처가 자기 자신 또는 TrustedOrigins 인지
+func (cs *CSRF) checkOrigin(c *Context) error {
+	origin := c.Req.Header.Get("Origin")
+	if origin == "" || origin == "null" {
+		ref := c.Req.Header.Get("Referer")
+		if ref == "" {
+			// HTTPS 에서는 Referer 가 없는 요청 거부 (OWASP 권고)
+			if c.Scheme == "https" {
+				return errors.New("missing origin and referer")
+			}
+			return nil
+		}
+		u, err := url.Parse(ref)
+		if err != nil || u.Host == "" {
+			return errors.New("invalid referer")
+		}
+		origin = u.Scheme + "://" + u.Host
+	}
+
+	if strings.EqualFold(origin, c.Scheme+"://"+c.Host) {
+		return nil
+	}
+	for _, o := range cs.TrustedOrigins {
+		if strings.EqualFold(origin, o) {
+			return nil
+		}
+	}
+	return errors.New("origin not allowed: " + origin)
+}
+
+func (cs *CSRF) fail(err error) {
+	NewAppError(CodeForbidden, err, map[string]any{"Reason": "csrf"}).Panic()
+}
+
+// 전처리기. 모든 요청에 토큰을 준비하고, 안전하지 않은 메서드는 출처와 토큰 검증
+func (cs *CSRF) Handler(c *Context) {
+	if err := cs.check(c.App); err != nil {
+		panic(err)
+	}
+	expected := cs.token(c)
+	c.Set(csrfStoreKey, expected)
+	c.Set(csrfFieldStoreKey, cs.FieldName)
+
+	if isSafeMethod(c.Req.Method) {
+		return
+	}
+	if err := cs.checkOrigin(c); err != nil {
+		cs.fail(err)
+	}
+
+	sent := c.Req.Header.Get(cs.HeaderName)
+	if sent == "" {
+		sent = c.Req.PostFormValue(cs.FieldName)
+	}
+	if sent == "" {
+		cs.fail(errors.New("missing csrf token"))
+	}
+	if subtle.ConstantTimeCompare([]byte(sent), []byte(expected)) != 1 {
+		cs.fail(errors.New("csrf token mismatch"))
+	}
+}
+
+// 현재 요청의 CSRF 토큰 (CSRF 전처리기 이후에만 유효)
+func (c *Context) CSRFToken() string {
+	t, _ := c.Get(csrfStoreKey).(string)
+	return t
+}
+
+// 폼에 넣을 hidden input (필드 이름은 CSRF.FieldName)
+func (c *Context) CSRFField() template.HTML {
+	name, _ := c.Get(csrfFieldStoreKey).(string)
+	return csrfField(name, c.CSRFToken())
+}
+
+// FieldName 을 쓰는 hidden input. 템플릿 함수로 등록할 때
+func (cs *CSRF) Field(token string) template.HTML {
+	return csrfField(cs.FieldName, token)
+}
+
+// 기본 필드 이름(csrf_token)의 hidden input
+func CSRFField(token string) template.HTML {
+	return csrfField(DefaultCSRFFieldName, token)
+}
+
+func csrfField(name, token string) template.HTML {
+	if name == "" {
+		name = DefaultCSRFFieldName
+	}
+	return template.HTML(`<input type="hidden" name="` + template.HTMLEscapeString(name) +
+		`" value="` + template.HTMLEscapeString(token) + `">`)
+}
+
+// AJAX 용 meta 태그
+func CSRFMeta(token string) template.HTML {
+	return template.HTML(`<meta name="csrf-token" content="` + template.HTMLEscapeString(token) + `">`)
+}
@@ -0,0 +1,149 @@
+package x
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+type csrfVisit struct {
+	cookies []*http.Cookie
+	token   string
+	field   string
+}
+
+// 폼을 열어서 쿠키와 토큰을 받음
+func visitForm(t *testing.T, a *App) csrfVisit {
+	t.Helper()
+	w := httptest.NewRecorder()
+	a.Server.Handler.ServeHTTP(w, httptest.NewRequest("GET", "/form", nil))
+	var res struct {
+		Data struct{ Token, Field string }
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil || res.Data.Token == "" {
+		t.Fatalf("form response %q: %v", w.Body.String(), err)
+	}
+	return csrfVisit{w.Result().Cookies(), res.Data.Token, res.Data.Field}
+}
+
+func TestCSRF(t *testing.T) {
+	a, _ := newCSRFApp()
+	alice := visitForm(t, a)
+	mallory := visitForm(t, a)
+
+	sessionOf := func(v csrfVisit) *http.Cookie {
+		for _, ck := range v.cookies {
+			if ck.Name == "sid" {
+				return ck
+			}
+		}
+		t.Fatal("no session cookie")
+		return nil
+	}
+	csrfOf := func(v csrfVisit) *http.Cookie {
+		for _, ck := range v.cookies {
+			if ck.Name == "csrf" {
+				return ck
+			}
+		}
+		t.Fatal("no csrf cookie")
+		return nil
+	}
+
+	tests := []struct {
+		name    string
+		cookies []*http.Cookie
+		header  string
+		form    string
+		origin  string
+		want    string
+	}{
+		{"header token", alice.cookies, alice.token, "", "", `"Code":"OK"`},
+		{"form field", alice.cookies, "", alice.token, "", `"Code":"OK"`},
+		{"missing token", alice.cookies, "", "", "", `"Code":"Forbidden"`},
+		{"forged token", alice.cookies, alice.token + "x", "", "", `"Code":"Forbidden"`},
+		// 다른 사용자의 쿠키와 토큰 쌍을 심어도 세션이 다르면 거부
+		{"other session's token", []*http.Cookie{sessionOf(alice), csrfOf(mallory)}, mallory.token, "", "", `"Code":"Forbidden"`},
+		{"cross origin", alice.cookies, alice.token, "", "http://evil.example", `"Code":"Forbidden"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/submit", strings.NewReader(url.Values{"_csrf": {tt.form}}.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			for _, ck := range tt.cookies {
+				r.AddCookie(ck)
+			}
+			if tt.header != "" {
+				r.Header.Set("X-CSRF-Token", tt.header)
+			}
+			if tt.origin != "" {
+				r.Header.Set("Origin", tt.origin)
+			}
+			w := httptest.NewRecorder()
+			a.Server.Handler.ServeHTTP(w, r)
+			if body := w.Body.String(); !strings.Contains(body, tt.want) {
+				t.Fatalf("body %q, want %s", body, tt.want)
+			}
+		})
+	}
+}
+
+func TestCSRFField(t *testing.T) {
+	a, csrf := newCSRFApp()
+	v := visitForm(t, a)
+	if !strings.Contains(v.field, `name="_csrf"`) || !strings.Contains(v.field, v.token) {
+		t.Fatalf("c.CSRFField() = %s", v.field)
+	}
+	if f := string(csrf.Field("t")); f != `<input type="hidden" name="_csrf" value="t">` {
+		t.Fatalf("csrf.Field = %s", f)
+	}
+	if f := string(CSRFField("t")); !strings.Contains(f, `name="csrf_token"`) {
+		t.Fatalf("CSRFField = %s", f)
+	}
+}
+
+func TestCSRFConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		sessions bool
+		subject  bool
+		mode     CSRFMode
+		err      string
+	}{
+		{"sessions", true, false, CSRFDoubleSubmit, ""},
+		{"subject without sessions", false, true, CSRFDoubleSubmit, ""},
+		{"neither", false, false, CSRFDoubleSubmit, "Subject or App.Sessions is required"},
+		{"synchronizer without sessions", false, true, CSRFSynchronizer, "CSRFSynchronizer requires App.Sessions"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewApp()
+			if tt.sessions {
+				a.Sessions = NewSessionManager(NewMemorySessionStore())
+			}
+			csrf := NewCSRF([]byte("csrf-key"))
+			csrf.Mode = tt.mode
+			if tt.subject {
+				csrf.Subject = func(c *Context) string { return c.RemoteIP }
+			}
+			err := csrf.check(a)
+			if tt.err == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				a.Router.AddPreprocessors(csrf.Handler)
+				a.Router.AddRoute(a, "GET", "/form", ReplyJSON)
+				if body := serve(a, httptest.NewRequest("GET", "/form", nil)); !strings.Contains(body, `"Code":"OK"`) {
+					t.Fatalf("body %q", body)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("err %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
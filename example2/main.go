@@ -1,6 +1,8 @@
 package main
 
 import (
+	"encoding/json"
+
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/simjinhyun/x"
 )
@@ -42,6 +44,8 @@ func main() {
 
 	a.Router.AddPreprocessors(PP1, PP2, PP3)
 	a.Router.AddRoute(a, "POST", "/hello", x.ReplyJSON, MDW1, MDW2, MDW3, About)
+	a.Router.AddRoute(a, "GET", "/address", x.ReplyJSON, AddressList)
+	a.Router.AddRoute(a, "POST", "/address", x.ReplyJSON, AddressAdd)
 
 	a.Run("localhost:7000", 5)
 }
@@ -59,3 +63,24 @@ func MDW5(c *x.Context) {}
 func About(c *x.Context) {
 	c.Response.Data = &Build
 }
+
+func AddressList(c *x.Context) {
+	c.Response.Data = c.Call("db1", "SPS_address_book_all").Rows()
+}
+
+func AddressAdd(c *x.Context) {
+	var in struct {
+		Name    string `json:"name"`
+		Age     int    `json:"age"`
+		Phone   string `json:"phone"`
+		Address string `json:"address"`
+	}
+	if err := json.NewDecoder(c.Req.Body).Decode(&in); err != nil {
+		x.NewAppError(x.CodeInvalidParameter, err, nil).Panic()
+	}
+	c.Tx("db1")
+	r := c.Call("db1", "SPI_address_book", x.ProcArgs{
+		"name": in.Name, "age": in.Age, "phone": in.Phone, "address": in.Address,
+	})
+	c.Response.Data = map[string]any{"RowsAffected": r.RowsAffected}
+}
@@ -2,6 +2,12 @@ module github.com/simjinhyun/x
 
 go 1.25.3
 
-require github.com/go-sql-driver/mysql v1.9.3
+require (
+	github.com/go-sql-driver/mysql v1.9.3
+	golang.org/x/crypto v0.43.0
+)
 
-require filippo.io/edwards25519 v1.1.0 // indirect
+require (
+	filippo.io/edwards25519 v1.1.0 // indirect
+	golang.org/x/sys v0.37.0 // indirect
+)
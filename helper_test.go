@@ -0,0 +1,239 @@
+package x
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing/fstest"
+
+	"github.com/simjinhyun/x/util"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// 테스트 공용 도우미
+// /////////////////////////////////////////////////////////////////////////////
+
+// 앱 핸들러로 요청을 처리한 결과
+func do(a *App, r *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	a.Server.Handler.ServeHTTP(w, r)
+	return w
+}
+
+// 본문만 필요한 경우
+func serve(a *App, r *http.Request) string {
+	return do(a, r).Body.String()
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// 픽스처
+// /////////////////////////////////////////////////////////////////////////////
+
+func newHMACApp() *App {
+	secret := []byte("shared-secret")
+	auth := NewHMACAuth(func(id string) ([]byte, bool) {
+		return secret, id == "svc-a"
+	})
+	a := NewApp()
+	a.MaxBodySize = 4 << 20
+	a.Router.AddRoute(a, "POST", "/sync", ReplyJSON, auth.Handler, func(c *Context) {
+		b, err := io.ReadAll(c.Req.Body)
+		if err != nil {
+			panic(err)
+		}
+		c.Response.Data = map[string]any{"Key": c.HMACKeyID(), "Len": len(b)}
+	})
+	return a
+}
+
+func newCSRFApp() (*App, *CSRF) {
+	a := NewApp()
+	a.Sessions = NewSessionManager(NewMemorySessionStore())
+	csrf := NewCSRF([]byte("csrf-key"))
+	csrf.FieldName = "_csrf"
+	a.Router.AddPreprocessors(csrf.Handler)
+	a.Router.AddRoute(a, "GET", "/form", ReplyJSON, func(c *Context) {
+		c.Response.Data = map[string]any{"Token": c.CSRFToken(), "Field": c.CSRFField()}
+	})
+	a.Router.AddRoute(a, "POST", "/submit", ReplyJSON)
+	return a, csrf
+}
+
+func newSignedURLApp(ts *util.TokenSigner) *App {
+	a := NewApp()
+	a.Router.FS = fstest.MapFS{
+		"downloads/secret.txt": {Data: []byte("secret")},
+		"public.txt":           {Data: []byte("public")},
+	}
+	a.Router.AddPreprocessors(RequireSignedURL(ts, "/downloads/"))
+	return a
+}
+
+// 세션 값을 읽고 쓰는 라우트를 가진 앱
+func newSessionApp(store SessionStore) *App {
+	a := NewApp()
+	a.Sessions = NewSessionManager(store)
+	a.Router.AddRoute(a, "POST", "/set", ReplyJSON, func(c *Context) {
+		c.Session().Set("v", c.Req.URL.Query().Get("v"))
+	})
+	a.Router.AddRoute(a, "GET", "/get", ReplyJSON, func(c *Context) {
+		c.Response.Data = c.Session().Get("v")
+	})
+	a.Router.AddRoute(a, "POST", "/login", ReplyJSON, func(c *Context) {
+		c.Session().Regenerate()
+	})
+	a.Router.AddRoute(a, "POST", "/logout", ReplyJSON, func(c *Context) {
+		c.Session().Destroy()
+	})
+	a.Router.AddRoute(a, "POST", "/flash", ReplyJSON, func(c *Context) {
+		c.Session().AddFlash("saved")
+	})
+	a.Router.AddRoute(a, "GET", "/flashes", ReplyJSON, func(c *Context) {
+		c.Response.Data = c.Session().Flashes()
+	})
+	return a
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// database/sql 스텁 드라이버
+// /////////////////////////////////////////////////////////////////////////////
+
+// 실행된 문장과 트랜잭션 동작을 기록하는 가짜 DB. 결과와 에러는 테스트에서 지정
+type stubDB struct {
+	mu        sync.Mutex
+	log       []string
+	execErr   error
+	commitErr error
+	affected  int64
+	sets      []stubSet // Query 결과셋
+}
+
+type stubSet struct {
+	cols  []string
+	types []string
+	rows  [][]driver.Value
+}
+
+func newStubDB() (*stubDB, *sql.DB) {
+	s := &stubDB{}
+	return s, sql.OpenDB(s)
+}
+
+func (s *stubDB) record(format string, args ...any) {
+	s.mu.Lock()
+	s.log = append(s.log, fmt.Sprintf(format, args...))
+	s.mu.Unlock()
+}
+
+func (s *stubDB) Log() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.log...)
+}
+
+func (s *stubDB) Connect(context.Context) (driver.Conn, error) { return &stubConn{db: s}, nil }
+func (s *stubDB) Driver() driver.Driver                        { return nil }
+
+type stubConn struct {
+	db   *stubDB
+	inTx bool
+}
+
+func (c *stubConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("stub: prepare not supported")
+}
+func (c *stubConn) Close() error { return nil }
+func (c *stubConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *stubConn) BeginTx(_ context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if opts.ReadOnly {
+		c.db.record("begin read only")
+	} else {
+		c.db.record("begin")
+	}
+	c.inTx = true
+	return &stubTx{c}, nil
+}
+
+func (c *stubConn) where() string {
+	if c.inTx {
+		return " (tx)"
+	}
+	return ""
+}
+
+func (c *stubConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.db.record("exec%s %s %d", c.where(), query, len(args))
+	if c.db.execErr != nil {
+		return nil, c.db.execErr
+	}
+	return driver.RowsAffected(c.db.affected), nil
+}
+
+func (c *stubConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.db.record("query%s %s %d", c.where(), query, len(args))
+	if c.db.execErr != nil {
+		return nil, c.db.execErr
+	}
+	return &stubRows{sets: c.db.sets}, nil
+}
+
+type stubTx struct{ c *stubConn }
+
+func (t *stubTx) Commit() error {
+	t.c.inTx = false
+	t.c.db.record("commit")
+	return t.c.db.commitErr
+}
+
+func (t *stubTx) Rollback() error {
+	t.c.inTx = false
+	t.c.db.record("rollback")
+	return nil
+}
+
+// 여러 결과셋 지원
+type stubRows struct {
+	sets []stubSet
+	set  int
+	row  int
+}
+
+func (r *stubRows) cur() stubSet {
+	if r.set < len(r.sets) {
+		return r.sets[r.set]
+	}
+	return stubSet{}
+}
+
+func (r *stubRows) Columns() []string                       { return r.cur().cols }
+func (r *stubRows) ColumnTypeDatabaseTypeName(i int) string { return r.cur().types[i] }
+func (r *stubRows) Close() error                            { return nil }
+func (r *stubRows) HasNextResultSet() bool                  { return r.set+1 < len(r.sets) }
+
+func (r *stubRows) NextResultSet() error {
+	if !r.HasNextResultSet() {
+		return io.EOF
+	}
+	r.set++
+	r.row = 0
+	return nil
+}
+
+func (r *stubRows) Next(dest []driver.Value) error {
+	s := r.cur()
+	if r.row >= len(s.rows) {
+		return io.EOF
+	}
+	copy(dest, s.rows[r.row])
+	r.row++
+	return nil
+}
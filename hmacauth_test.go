@@ -0,0 +1,100 @@
+package x
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/simjinhyun/x/util"
+)
+
+func signedRequest(t *testing.T, body []byte, secret string) *http.Request {
+	t.Helper()
+	r := httptest.NewRequest("POST", "/sync", bytes.NewReader(body))
+	if err := util.HMACSigner("svc-a", []byte(secret))(r, body); err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestHMACAuth(t *testing.T) {
+	a := newHMACApp()
+	big := bytes.Repeat([]byte("a"), 2<<20)
+
+	tests := []struct {
+		name string
+		req  func() *http.Request
+		want string
+	}{
+		{"small body", func() *http.Request {
+			return signedRequest(t, []byte(`{"n":1}`), "shared-secret")
+		}, `"Len":7`},
+		{"body over 1MB", func() *http.Request {
+			return signedRequest(t, big, "shared-secret")
+		}, `"Len":2097152`},
+		{"body tampered after 1MB", func() *http.Request {
+			r := signedRequest(t, big, "shared-secret")
+			tampered := bytes.Clone(big)
+			tampered[len(tampered)-1] = 'b'
+			r.Body = io.NopCloser(bytes.NewReader(tampered))
+			return r
+		}, `"Code":"Unauthorized"`},
+		{"multipart", func() *http.Request {
+			r := signedRequest(t, []byte("--x\r\n\r\nv\r\n--x--\r\n"), "shared-secret")
+			r.Header.Set("Content-Type", "multipart/form-data; boundary=x")
+			return r
+		}, `"Key":"svc-a"`},
+		{"wrong secret", func() *http.Request {
+			return signedRequest(t, nil, "other")
+		}, `"Code":"Unauthorized"`},
+		{"clock skew", func() *http.Request {
+			r := signedRequest(t, nil, "shared-secret")
+			r.Header.Set(util.HeaderTimestamp, strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10))
+			return r
+		}, `"Code":"Unauthorized"`},
+		{"too large", func() *http.Request {
+			return signedRequest(t, bytes.Repeat([]byte("a"), 5<<20), "shared-secret")
+		}, `"Code":"PayloadTooLarge"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if body := serve(a, tt.req()); !strings.Contains(body, tt.want) {
+				t.Fatalf("body %q, want %s", body, tt.want)
+			}
+		})
+	}
+}
+
+func TestHMACAuthReplay(t *testing.T) {
+	a := newHMACApp()
+	r := signedRequest(t, []byte("x"), "shared-secret")
+	replay := r.Clone(r.Context())
+	replay.Body = io.NopCloser(strings.NewReader("x"))
+
+	if body := serve(a, r); !strings.Contains(body, `"Code":"OK"`) {
+		t.Fatalf("first request: %s", body)
+	}
+	if body := serve(a, replay); !strings.Contains(body, `"Code":"Unauthorized"`) {
+		t.Fatalf("replayed request: %s", body)
+	}
+}
+
+// 전역 전처리기에서는 c.Route 가 없지만 라우트의 크기 제한이 적용되어야 함
+func TestHMACAuthRouteBodyLimit(t *testing.T) {
+	secret := []byte("shared-secret")
+	auth := NewHMACAuth(func(id string) ([]byte, bool) { return secret, true })
+	a := NewApp()
+	a.MaxBodySize = 10
+	a.Router.AddPreprocessors(auth.Handler)
+	a.Router.AddRoute(a, "POST", "/sync", ReplyJSON).MaxBodySize = 100
+
+	body := bytes.Repeat([]byte("a"), 50)
+	if got := serve(a, signedRequest(t, body, "shared-secret")); !strings.Contains(got, `"Code":"OK"`) {
+		t.Fatalf("body %q", got)
+	}
+}
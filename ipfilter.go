@@ -0,0 +1,253 @@
+package x
+
+import (
+	"bufio"
+	"fmt"
+	"net/netip"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// IP 허용/차단 목록
+// /////////////////////////////////////////////////////////////////////////////
+
+/*
+비트 단위 radix 트리. IPv4 와 IPv6 는 루트를 따로 둠
+하나의 트리에 IPv4-mapped 로 넣으면 "::/1" 같은 IPv6 규칙이 모든 IPv4 에 걸림
+*/
+type cidrTree struct {
+	root4 cidrNode
+	root6 cidrNode
+	size  int
+}
+
+type cidrNode struct {
+	child [2]*cidrNode
+	term  bool
+}
+
+// 주소 바이트와 해당 패밀리의 루트
+func (t *cidrTree) rootOf(addr netip.Addr) (*cidrNode, []byte) {
+	if addr.Is4() {
+		b := addr.As4()
+		return &t.root4, b[:]
+	}
+	b := addr.As16()
+	return &t.root6, b[:]
+}
+
+func (t *cidrTree) insert(p netip.Prefix) {
+	p = p.Masked()
+	n, b := t.rootOf(p.Addr())
+	for i := 0; i < p.Bits(); i++ {
+		if n.term {
+			return // 이미 더 넓은 대역이 있음
+		}
+		bit := (b[i/8] >> (7 - i%8)) & 1
+		if n.child[bit] == nil {
+			n.child[bit] = &cidrNode{}
+		}
+		n = n.child[bit]
+	}
+	n.term = true
+	n.child = [2]*cidrNode{} // 하위 대역은 불필요
+	t.size++
+}
+
+// addr 는 Unmap 된 주소여야 함 (IPv4-mapped 는 IPv4 로)
+func (t *cidrTree) contains(addr netip.Addr) bool {
+	n, b := t.rootOf(addr)
+	for i := 0; i < len(b)*8; i++ {
+		if n.term {
+			return true
+		}
+		n = n.child[(b[i/8]>>(7-i%8))&1]
+		if n == nil {
+			return false
+		}
+	}
+	return n.term
+}
+
+/*
+"10.0.0.0/8" 또는 단일 주소 "1.2.3.4"
+"::ffff:10.0.0.0/104" 처럼 IPv4-mapped 대역 안의 규칙은 IPv4 규칙으로 바꿈
+*/
+func parsePrefix(s string) (netip.Prefix, error) {
+	var p netip.Prefix
+	if strings.Contains(s, "/") {
+		var err error
+		if p, err = netip.ParsePrefix(s); err != nil {
+			return p, err
+		}
+	} else {
+		addr, err := netip.ParseAddr(s)
+		if err != nil {
+			return p, err
+		}
+		p = netip.PrefixFrom(addr, addr.BitLen())
+	}
+	if p.Addr().Is4In6() && p.Bits() >= 96 {
+		p = netip.PrefixFrom(p.Addr().Unmap(), p.Bits()-96)
+	}
+	return p, nil
+}
+
+/*
+c.RemoteIP 기준 허용/차단. 차단 목록이 우선이고, 허용 목록이 비어있지 않으면 목록에 없는 IP 는 거부
+RemoteIP 를 믿으려면 App.SetTrustedProxies 를 먼저 설정할것
+
+	office := x.NewIPFilter()
+	office.Allow("203.0.113.0/24", "10.0.0.0/8")
+	a.Router.AddRoute(a, "GET", "/admin", x.ReplyJSON, office.Handler, Admin)
+
+	blocklist := x.NewIPFilter()
+	blocklist.LoadFile("/etc/app/blocklist.txt")
+	blocklist.ReloadOnSIGHUP(a)
+	a.Router.AddPreprocessors(blocklist.Handler)
+*/
+type IPFilter struct {
+	Path string // LoadFile 로 읽은 파일. SIGHUP 시 다시 읽음 (직접 바꾸지 말고 LoadFile 사용)
+
+	mu    sync.RWMutex
+	allow *cidrTree
+	deny  *cidrTree
+}
+
+func NewIPFilter() *IPFilter {
+	return &IPFilter{allow: &cidrTree{}, deny: &cidrTree{}}
+}
+
+func (f *IPFilter) Allow(cidrs ...string) error {
+	return f.add(false, cidrs)
+}
+
+func (f *IPFilter) Deny(cidrs ...string) error {
+	return f.add(true, cidrs)
+}
+
+func (f *IPFilter) add(deny bool, cidrs []string) error {
+	prefixes := make([]netip.Prefix, 0, len(cidrs))
+	for _, s := range cidrs {
+		p, err := parsePrefix(strings.TrimSpace(s))
+		if err != nil {
+			return err
+		}
+		prefixes = append(prefixes, p)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	t := f.allow
+	if deny {
+		t = f.deny
+	}
+	for _, p := range prefixes {
+		t.insert(p)
+	}
+	return nil
+}
+
+/*
+파일에서 목록 읽기. 기존 목록을 통째로 교체하며 파싱 실패 시 기존 목록 유지
+한 줄에 하나, "#" 이후는 주석. 키워드가 없으면 deny
+
+	allow 10.0.0.0/8
+	deny  198.51.100.0/24
+	192.0.2.7
+*/
+func (f *IPFilter) LoadFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	allow, deny := &cidrTree{}, &cidrTree{}
+	sc := bufio.NewScanner(file)
+	lineNo := 0
+	for sc.Scan() {
+		lineNo++
+		line, _, _ := strings.Cut(sc.Text(), "#")
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		t, cidr := deny, fields[0]
+		if len(fields) == 2 {
+			switch strings.ToLower(fields[0]) {
+			case "allow":
+				t = allow
+			case "deny":
+			default:
+				return fmt.Errorf("%s:%d: unknown keyword %q", path, lineNo, fields[0])
+			}
+			cidr = fields[1]
+		} else if len(fields) > 2 {
+			return fmt.Errorf("%s:%d: invalid line", path, lineNo)
+		}
+
+		p, err := parsePrefix(cidr)
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", path, lineNo, err)
+		}
+		t.insert(p)
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	f.allow, f.deny, f.Path = allow, deny, path
+	f.mu.Unlock()
+	return nil
+}
+
+// SIGHUP 수신 시 Path 다시 읽기. 기존 SIGHUP 핸들러는 유지됨
+func (f *IPFilter) ReloadOnSIGHUP(a *App) {
+	prev := a.OnSignal[syscall.SIGHUP]
+	a.RegisterSignal(syscall.SIGHUP, func() {
+		if prev != nil {
+			prev()
+		}
+		// Path 는 LoadFile 이 잠금 안에서 바꿈
+		f.mu.RLock()
+		path := f.Path
+		f.mu.RUnlock()
+		if path == "" {
+			return
+		}
+		if err := f.LoadFile(path); err != nil {
+			a.Logger.Error("IPFilter reload failed", path, err)
+			return
+		}
+		a.Logger.Info("IPFilter reloaded", path)
+	})
+}
+
+// IP 허용 여부
+func (f *IPFilter) Permit(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap().WithZone("")
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if f.deny.contains(addr) {
+		return false
+	}
+	return f.allow.size == 0 || f.allow.contains(addr)
+}
+
+// 전처리기/핸들러
+func (f *IPFilter) Handler(c *Context) {
+	if !f.Permit(c.RemoteIP) {
+		NewAppError(CodeForbidden, fmt.Errorf("ip %s not permitted", c.RemoteIP), nil).Panic()
+	}
+}
@@ -0,0 +1,77 @@
+package x
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestIPFilterPermit(t *testing.T) {
+	tests := []struct {
+		name  string
+		allow []string
+		deny  []string
+		ip    string
+		want  bool
+	}{
+		{"no rules", nil, nil, "1.2.3.4", true},
+		{"denied v4", nil, []string{"10.0.0.0/8"}, "10.1.2.3", false},
+		{"denied mapped", nil, []string{"10.0.0.0/8"}, "::ffff:10.1.2.3", false},
+		{"v6 rule does not cover v4", nil, []string{"::/1"}, "1.2.3.4", true},
+		{"v6 rule", nil, []string{"::/1"}, "::1", false},
+		{"mapped rule as v4", nil, []string{"::ffff:10.0.0.0/104"}, "10.1.2.3", false},
+		{"allowed", []string{"203.0.113.0/24"}, nil, "203.0.113.9", true},
+		{"not in allow list", []string{"203.0.113.0/24"}, nil, "198.51.100.1", false},
+		{"v4 allow list rejects v6", []string{"0.0.0.0/0"}, nil, "2001:db8::1", false},
+		{"deny wins", []string{"10.0.0.0/8"}, []string{"10.0.0.1"}, "10.0.0.1", false},
+		{"invalid ip", nil, nil, "bogus", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewIPFilter()
+			if err := f.Allow(tt.allow...); err != nil {
+				t.Fatal(err)
+			}
+			if err := f.Deny(tt.deny...); err != nil {
+				t.Fatal(err)
+			}
+			if got := f.Permit(tt.ip); got != tt.want {
+				t.Fatalf("Permit(%q) = %v, want %v", tt.ip, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIPFilterReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocklist.txt")
+	if err := os.WriteFile(path, []byte("192.0.2.7 # 주석\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	a := NewApp()
+	f := NewIPFilter()
+	if err := f.LoadFile(path); err != nil {
+		t.Fatal(err)
+	}
+	f.ReloadOnSIGHUP(a)
+	if f.Permit("192.0.2.7") {
+		t.Fatal("loaded rule not applied")
+	}
+
+	if err := os.WriteFile(path, []byte("deny 198.51.100.0/24\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	a.OnSignal[syscall.SIGHUP]()
+	if !f.Permit("192.0.2.7") || f.Permit("198.51.100.1") {
+		t.Fatal("reloaded rules not applied")
+	}
+
+	// 파싱 실패 시 기존 목록 유지
+	if err := os.WriteFile(path, []byte("maybe 10.0.0.0/8\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	a.OnSignal[syscall.SIGHUP]()
+	if f.Permit("198.51.100.1") {
+		t.Fatal("rules dropped after failed reload")
+	}
+}
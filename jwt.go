@@ -0,0 +1,456 @@
+package x
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// JWT 인증
+// /////////////////////////////////////////////////////////////////////////////
+
+// 검증된 클레임
+type JWTClaims map[string]any
+
+func (cl JWTClaims) String(name string) string {
+	s, _ := cl[name].(string)
+	return s
+}
+
+func (cl JWTClaims) Subject() string { return cl.String("sub") }
+
+// 숫자형 시간 클레임 (exp, nbf, iat)
+func (cl JWTClaims) Time(name string) (time.Time, bool) {
+	switch v := cl[name].(type) {
+	case float64:
+		return time.Unix(int64(v), 0), true
+	case json.Number:
+		f, err := v.Float64()
+		return time.Unix(int64(f), 0), err == nil
+	}
+	return time.Time{}, false
+}
+
+// aud 는 문자열 또는 배열
+func (cl JWTClaims) Audience() []string {
+	switch v := cl["aud"].(type) {
+	case string:
+		return []string{v}
+	case []any:
+		out := make([]string, 0, len(v))
+		for _, a := range v {
+			if s, ok := a.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// 서명 검증용 키 조회. kid 가 없는 토큰은 kid = ""
+type KeySource interface {
+	Key(kid, alg string) (any, error)
+}
+
+// 고정 키. HS256 은 []byte, RS256 은 *rsa.PublicKey, ES256 은 *ecdsa.PublicKey, EdDSA 는 ed25519.PublicKey
+type StaticKeys map[string]any
+
+func (s StaticKeys) Key(kid, alg string) (any, error) {
+	if k, ok := s[kid]; ok {
+		return k, nil
+	}
+	return nil, fmt.Errorf("unknown kid %q", kid)
+}
+
+type JWTVerifier struct {
+	Keys       KeySource
+	Algorithms []string // 허용 알고리즘
+	Issuer     string   // 비어있으면 검사 안함
+	Audience   []string // 하나라도 일치하면 통과. 비어있으면 검사 안함
+	Leeway     time.Duration
+	RequireExp bool // exp 가 없는 토큰 거부 (기본값 true)
+}
+
+/*
+JWT 검증 전처리기 생성
+v := x.NewJWTVerifier(x.NewJWKSFile("/etc/app/jwks.json"))
+v.Issuer, v.Audience = "https://auth.example.com", []string{"api"}
+a.Router.AddRoute(a, "GET", "/me", x.ReplyJSON, v.Handler, Me)  // c.Claims 사용
+*/
+func NewJWTVerifier(keys KeySource) *JWTVerifier {
+	return &JWTVerifier{
+		Keys:       keys,
+		Algorithms: []string{"HS256", "RS256", "ES256", "EdDSA"},
+		Leeway:     30 * time.Second,
+		RequireExp: true,
+	}
+}
+
+var b64url = base64.RawURLEncoding
+
+func (v *JWTVerifier) Verify(token string) (JWTClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("jwt: malformed token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	hb, err := b64url.DecodeString(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("jwt: header: %w", err)
+	}
+	if err := json.Unmarshal(hb, &header); err != nil {
+		return nil, fmt.Errorf("jwt: header: %w", err)
+	}
+	allowed := false
+	for _, a := range v.Algorithms {
+		if a == header.Alg {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return nil, fmt.Errorf("jwt: algorithm %q not allowed", header.Alg)
+	}
+
+	sig, err := b64url.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("jwt: signature: %w", err)
+	}
+	key, err := v.Keys.Key(header.Kid, header.Alg)
+	if err != nil {
+		return nil, fmt.Errorf("jwt: %w", err)
+	}
+	if err := verifySignature(header.Alg, key, []byte(parts[0]+"."+parts[1]), sig); err != nil {
+		return nil, err
+	}
+
+	pb, err := b64url.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("jwt: payload: %w", err)
+	}
+	var claims JWTClaims
+	if err := json.Unmarshal(pb, &claims); err != nil {
+		return nil, fmt.Errorf("jwt: payload: %w", err)
+	}
+	if err := v.validate(claims, time.Now()); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// 알고리즘과 키 타입이 맞지 않으면 거부 (공개키를 HMAC 비밀로 쓰는 공격 방지)
+func verifySignature(alg string, key any, signed, sig []byte) error {
+	digest := sha256.Sum256(signed)
+	bad := errors.New("jwt: invalid signature")
+
+	switch alg {
+	case "HS256":
+		k, ok := key.([]byte)
+		if !ok {
+			return errors.New("jwt: HS256 requires []byte key")
+		}
+		m := hmac.New(sha256.New, k)
+		m.Write(signed)
+		if !hmac.Equal(sig, m.Sum(nil)) {
+			return bad
+		}
+	case "RS256":
+		k, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return errors.New("jwt: RS256 requires RSA public key")
+		}
+		if rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], sig) != nil {
+			return bad
+		}
+	case "ES256":
+		k, ok := key.(*ecdsa.PublicKey)
+		if !ok || k.Curve != elliptic.P256() {
+			return errors.New("jwt: ES256 requires P-256 public key")
+		}
+		if len(sig) != 64 {
+			return bad
+		}
+		r, s := new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])
+		if !ecdsa.Verify(k, digest[:], r, s) {
+			return bad
+		}
+	case "EdDSA":
+		k, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return errors.New("jwt: EdDSA requires Ed25519 public key")
+		}
+		if !ed25519.Verify(k, signed, sig) {
+			return bad
+		}
+	default:
+		return fmt.Errorf("jwt: unsupported algorithm %q", alg)
+	}
+	return nil
+}
+
+func (v *JWTVerifier) validate(cl JWTClaims, now time.Time) error {
+	exp, ok := cl.Time("exp")
+	if !ok && v.RequireExp {
+		return errors.New("jwt: exp required")
+	}
+	if ok && now.After(exp.Add(v.Leeway)) {
+		return errors.New("jwt: token expired")
+	}
+	if nbf, ok := cl.Time("nbf"); ok && now.Add(v.Leeway).Before(nbf) {
+		return errors.New("jwt: token not yet valid")
+	}
+	if v.Issuer != "" && cl.String("iss") != v.Issuer {
+		return errors.New("jwt: issuer mismatch")
+	}
+	if len(v.Audience) > 0 {
+		for _, want := range v.Audience {
+			for _, got := range cl.Audience() {
+				if want == got {
+					return nil
+				}
+			}
+		}
+		return errors.New("jwt: audience mismatch")
+	}
+	return nil
+}
+
+// 전처리기/핸들러. Authorization: Bearer <token>
+func (v *JWTVerifier) Handler(c *Context) {
+	auth := c.Req.Header.Get("Authorization")
+	scheme, token, ok := strings.Cut(auth, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		c.Res.Header().Set("WWW-Authenticate", `Bearer`)
+		NewAppError(CodeUnauthorized, errors.New("bearer token required"), nil).Panic()
+	}
+
+	claims, err := v.Verify(strings.TrimSpace(token))
+	if err != nil {
+		c.Res.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+		NewAppError(CodeUnauthorized, err, nil).Panic()
+	}
+	c.Claims = claims
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// JWKS : 파일 또는 URL, 캐시 + kid 기반 교체
+// /////////////////////////////////////////////////////////////////////////////
+
+/*
+JWKS 키 소스. TTL 마다 다시 읽고, 모르는 kid 가 오면 MinRefresh 간격을 두고 즉시 다시 읽음
+키 교체 시 새 키를 JWKS 에 먼저 추가해두면 무중단으로 전환됨
+읽는 동안 락을 잡지 않으며, TTL 이 지난 키도 새로 읽을 때까지 계속 사용
+*/
+type JWKS struct {
+	TTL        time.Duration
+	MinRefresh time.Duration
+	fetch      func() ([]byte, error)
+
+	mu      sync.Mutex
+	keys    map[string]any
+	fetched time.Time
+	pending *jwksFetch // 진행 중인 읽기. 동시에 하나만
+}
+
+type jwksFetch struct {
+	done chan struct{}
+	keys map[string]any
+	err  error
+}
+
+func NewJWKSFile(path string) *JWKS {
+	return newJWKS(func() ([]byte, error) { return os.ReadFile(path) })
+}
+
+func NewJWKSURL(url string) *JWKS {
+	client := &http.Client{Timeout: 5 * time.Second}
+	return newJWKS(func() ([]byte, error) {
+		resp, err := client.Get(url)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("jwks: HTTP %d", resp.StatusCode)
+		}
+		return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	})
+}
+
+func newJWKS(fetch func() ([]byte, error)) *JWKS {
+	return &JWKS{
+		TTL:        10 * time.Minute,
+		MinRefresh: 30 * time.Second,
+		fetch:      fetch,
+	}
+}
+
+func (j *JWKS) Key(kid, alg string) (any, error) {
+	j.mu.Lock()
+	keys, age, pending := j.keys, time.Since(j.fetched), j.pending != nil
+	j.mu.Unlock()
+
+	switch {
+	case keys == nil:
+		// 처음에는 읽을 때까지 기다림
+		var err error
+		if keys, err = j.refresh(); keys == nil {
+			return nil, err
+		}
+	case age > j.TTL && !pending:
+		// 만료된 키로 계속 응답하면서 뒤에서 갱신
+		go j.refresh()
+	}
+	if k, ok := keys[kid]; ok {
+		return k, nil
+	}
+	// 새 kid 일 수 있으므로 한 번 더 읽기 (이미 읽는 중이면 그 결과를 기다림)
+	if pending || age > j.MinRefresh {
+		keys, err := j.refresh()
+		if k, ok := keys[kid]; ok {
+			return k, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("unknown kid %q", kid)
+}
+
+// 키를 다시 읽음. 진행 중인 읽기가 있으면 같이 기다림. 실패해도 기존 키는 유지
+func (j *JWKS) refresh() (map[string]any, error) {
+	j.mu.Lock()
+	if f := j.pending; f != nil {
+		j.mu.Unlock()
+		<-f.done
+		return f.keys, f.err
+	}
+	f := &jwksFetch{done: make(chan struct{})}
+	j.pending = f
+	j.fetched = time.Now()
+	j.mu.Unlock()
+
+	var keys map[string]any
+	b, err := j.fetch()
+	if err == nil {
+		keys, err = ParseJWKS(b)
+	}
+
+	j.mu.Lock()
+	if err == nil {
+		j.keys = keys
+	}
+	f.keys, f.err = j.keys, err
+	j.pending = nil
+	j.mu.Unlock()
+	close(f.done)
+	return f.keys, f.err
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	K   string `json:"k"`
+}
+
+// JWKS 문서를 kid → 공개키 맵으로 변환. 서명용이 아닌 키(use=enc)와 모르는 타입은 건너뜀
+func ParseJWKS(b []byte) (map[string]any, error) {
+	var doc struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return nil, fmt.Errorf("jwks: %w", err)
+	}
+
+	keys := map[string]any{}
+	for _, k := range doc.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("jwks: kid %q: %w", k.Kid, err)
+		}
+		if key != nil {
+			keys[k.Kid] = key
+		}
+	}
+	return keys, nil
+}
+
+func (k jwk) publicKey() (any, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err1 := b64url.DecodeString(k.N)
+		e, err2 := b64url.DecodeString(k.E)
+		if err1 != nil || err2 != nil {
+			return nil, errors.New("invalid RSA key")
+		}
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, nil
+		}
+		x, err1 := b64url.DecodeString(k.X)
+		y, err2 := b64url.DecodeString(k.Y)
+		if err1 != nil || err2 != nil {
+			return nil, errors.New("invalid EC key")
+		}
+		// 압축되지 않은 점 형식으로 만들어 곡선 위의 점인지 검증
+		point := append([]byte{4}, append(leftPad(x, 32), leftPad(y, 32)...)...)
+		return ecdsa.ParseUncompressedPublicKey(elliptic.P256(), point)
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, nil
+		}
+		x, err := b64url.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	case "oct":
+		secret, err := b64url.DecodeString(k.K)
+		if err != nil {
+			return nil, errors.New("invalid oct key")
+		}
+		return secret, nil
+	}
+	return nil, nil
+}
+
+func leftPad(b []byte, n int) []byte {
+	if len(b) >= n {
+		return b
+	}
+	return append(make([]byte, n-len(b)), b...)
+}
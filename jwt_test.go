@@ -0,0 +1,287 @@
+package x
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// 테스트용 JWT 발급
+func signJWT(t *testing.T, alg, kid string, key any, claims map[string]any) string {
+	t.Helper()
+	hb, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	pb, _ := json.Marshal(claims)
+	signed := b64url.EncodeToString(hb) + "." + b64url.EncodeToString(pb)
+	digest := sha256.Sum256([]byte(signed))
+
+	var sig []byte
+	switch k := key.(type) {
+	case []byte:
+		m := hmac.New(sha256.New, k)
+		m.Write([]byte(signed))
+		sig = m.Sum(nil)
+	case *rsa.PrivateKey:
+		sig, _ = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig = append(leftPad(r.Bytes(), 32), leftPad(s.Bytes(), 32)...)
+	case ed25519.PrivateKey:
+		sig = ed25519.Sign(k, []byte(signed))
+	case nil:
+	default:
+		t.Fatalf("unsupported key %T", key)
+	}
+	return signed + "." + b64url.EncodeToString(sig)
+}
+
+func TestJWTVerify(t *testing.T) {
+	secret := []byte("hs-secret")
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	edPub, edKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	v := NewJWTVerifier(StaticKeys{
+		"hs": secret,
+		"rs": &rsaKey.PublicKey,
+		"ec": &ecKey.PublicKey,
+		"ed": edPub,
+	})
+	v.Issuer = "https://auth.example.com"
+	v.Audience = []string{"api"}
+
+	now := time.Now().Unix()
+	ok := map[string]any{"sub": "u1", "iss": v.Issuer, "aud": "api", "exp": now + 60}
+	with := func(k string, val any) map[string]any {
+		cl := map[string]any{}
+		for kk, vv := range ok {
+			cl[kk] = vv
+		}
+		cl[k] = val
+		return cl
+	}
+	rsaModulus := rsaKey.PublicKey.N.Bytes()
+
+	tests := []struct {
+		name  string
+		token string
+		err   string
+	}{
+		{"HS256", signJWT(t, "HS256", "hs", secret, ok), ""},
+		{"RS256", signJWT(t, "RS256", "rs", rsaKey, ok), ""},
+		{"ES256", signJWT(t, "ES256", "ec", ecKey, ok), ""},
+		{"EdDSA", signJWT(t, "EdDSA", "ed", edKey, ok), ""},
+		{"audience list", signJWT(t, "HS256", "hs", secret, with("aud", []string{"web", "api"})), ""},
+		{"alg none", signJWT(t, "none", "hs", nil, ok), "not allowed"},
+		{"wrong secret", signJWT(t, "HS256", "hs", []byte("other"), ok), "invalid signature"},
+		// RSA 공개키를 HMAC 비밀로 쓰는 공격
+		{"alg confusion", signJWT(t, "HS256", "rs", rsaModulus, ok), "requires []byte key"},
+		{"unknown kid", signJWT(t, "HS256", "nope", secret, ok), "unknown kid"},
+		{"no exp", signJWT(t, "HS256", "hs", secret, with("exp", nil)), "exp required"},
+		{"expired", signJWT(t, "HS256", "hs", secret, with("exp", now-120)), "expired"},
+		{"within leeway", signJWT(t, "HS256", "hs", secret, with("exp", now-10)), ""},
+		{"not yet valid", signJWT(t, "HS256", "hs", secret, with("nbf", now+120)), "not yet valid"},
+		{"issuer", signJWT(t, "HS256", "hs", secret, with("iss", "evil")), "issuer mismatch"},
+		{"audience", signJWT(t, "HS256", "hs", secret, with("aud", "web")), "audience mismatch"},
+		{"malformed", "a.b", "malformed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cl, err := v.Verify(tt.token)
+			if tt.err == "" {
+				if err != nil || cl.Subject() != "u1" {
+					t.Fatalf("claims %v, err %v", cl, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("err %v, want %q", err, tt.err)
+			}
+		})
+	}
+
+	// exp 없는 토큰은 RequireExp 를 끈 경우에만 허용
+	noExp := with("sub", "u1")
+	delete(noExp, "exp")
+	token := signJWT(t, "HS256", "hs", secret, noExp)
+	if _, err := v.Verify(token); err == nil || !strings.Contains(err.Error(), "exp required") {
+		t.Fatalf("token without exp: %v", err)
+	}
+	v.RequireExp = false
+	if _, err := v.Verify(token); err != nil {
+		t.Fatalf("RequireExp off: %v", err)
+	}
+}
+
+func TestParseJWKS(t *testing.T) {
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	edPub, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	doc := fmt.Sprintf(`{"keys":[
+		{"kty":"EC","kid":"ec","crv":"P-256","x":%q,"y":%q},
+		{"kty":"OKP","kid":"ed","crv":"Ed25519","x":%q},
+		{"kty":"RSA","kid":"enc","use":"enc","n":"AQAB","e":"AQAB"}
+	]}`,
+		b64url.EncodeToString(ecKey.PublicKey.X.Bytes()),
+		b64url.EncodeToString(ecKey.PublicKey.Y.Bytes()),
+		b64url.EncodeToString(edPub))
+
+	keys, err := ParseJWKS([]byte(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := keys["enc"]; ok || len(keys) != 2 {
+		t.Fatalf("keys = %v", keys)
+	}
+
+	v := NewJWTVerifier(StaticKeys(keys))
+	claims := map[string]any{"sub": "u1", "exp": time.Now().Unix() + 60}
+	for _, tok := range []string{
+		signJWT(t, "ES256", "ec", ecKey, claims),
+		signJWT(t, "EdDSA", "ed", edKey, claims),
+	} {
+		if _, err := v.Verify(tok); err != nil {
+			t.Error(err)
+		}
+	}
+
+	// 곡선 위에 없는 점은 거부
+	bad := `{"keys":[{"kty":"EC","kid":"x","crv":"P-256","x":"AQ","y":"AQ"}]}`
+	if _, err := ParseJWKS([]byte(bad)); err == nil {
+		t.Error("invalid EC point accepted")
+	}
+}
+
+func TestJWTHandler(t *testing.T) {
+	secret := []byte("hs-secret")
+	a := NewApp()
+	v := NewJWTVerifier(StaticKeys{"": secret})
+	a.Router.AddRoute(a, "GET", "/me", ReplyJSON, v.Handler, func(c *Context) {
+		c.Response.Data = c.Claims.Subject()
+	})
+
+	tests := []struct {
+		name string
+		auth string
+		want string
+	}{
+		{"valid", "Bearer " + signJWT(t, "HS256", "", secret, map[string]any{"sub": "u1", "exp": time.Now().Unix() + 60}), `"Data":"u1"`},
+		{"missing", "", `"Code":"Unauthorized"`},
+		{"basic scheme", "Basic dTE6cHc=", `"Code":"Unauthorized"`},
+		{"invalid", "Bearer a.b.c", `"Code":"Unauthorized"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/me", nil)
+			if tt.auth != "" {
+				r.Header.Set("Authorization", tt.auth)
+			}
+			w := httptest.NewRecorder()
+			a.Server.Handler.ServeHTTP(w, r)
+			if body := w.Body.String(); !strings.Contains(body, tt.want) {
+				t.Fatalf("body %q, want %s", body, tt.want)
+			}
+		})
+	}
+}
+
+func TestJWKSRefresh(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		doc     = `{"keys":[{"kty":"oct","kid":"k1","k":"czE"}]}`
+		fail    error
+		gate    chan struct{}
+		fetches int
+	)
+	j := newJWKS(func() ([]byte, error) {
+		mu.Lock()
+		fetches++
+		b, err, wait := doc, fail, gate
+		mu.Unlock()
+		if wait != nil {
+			<-wait
+		}
+		return []byte(b), err
+	})
+	set := func(d string, err error, g chan struct{}) {
+		mu.Lock()
+		doc, fail, gate = d, err, g
+		mu.Unlock()
+	}
+	key := func(kid string) string {
+		k, err := j.Key(kid, "HS256")
+		if err != nil {
+			return err.Error()
+		}
+		return string(k.([]byte))
+	}
+
+	if got := key("k1"); got != "s1" {
+		t.Fatalf("first fetch: %q", got)
+	}
+
+	// TTL 이 지나면 읽는 동안에도 기존 키로 바로 응답
+	j.TTL = 0
+	release := make(chan struct{})
+	set(`{"keys":[{"kty":"oct","kid":"k1","k":"czI"}]}`, nil, release)
+	done := make(chan string)
+	go func() { done <- key("k1") }()
+	select {
+	case got := <-done:
+		if got != "s1" {
+			t.Fatalf("stale key: %q", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Key blocked on refresh")
+	}
+	close(release)
+	for deadline := time.Now().Add(time.Second); key("k1") != "s2"; {
+		if time.Now().After(deadline) {
+			t.Fatal("keys not refreshed")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// 실패하면 기존 키 유지
+	j.TTL = time.Hour
+	j.MinRefresh = 0
+	set(`{}`, errors.New("down"), nil)
+	if got := key("k1"); got != "s2" {
+		t.Fatalf("after failed fetch: %q", got)
+	}
+	if got := key("k2"); got != "down" {
+		t.Fatalf("unknown kid with failing fetch: %q", got)
+	}
+
+	// 모르는 kid 는 다시 읽어서 찾되, MinRefresh 안에는 다시 읽지 않음
+	set(`{"keys":[{"kty":"oct","kid":"k2","k":"czM"}]}`, nil, nil)
+	if got := key("k2"); got != "s3" {
+		t.Fatalf("new kid: %q", got)
+	}
+	j.MinRefresh = time.Hour
+	mu.Lock()
+	before := fetches
+	mu.Unlock()
+	if got := key("k9"); !strings.Contains(got, "unknown kid") {
+		t.Fatalf("unknown kid: %q", got)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if fetches != before {
+		t.Fatalf("fetched %d times within MinRefresh", fetches-before)
+	}
+}
@@ -0,0 +1,508 @@
+package x
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// 콘텐츠 협상 (ReplyAuto)
+// /////////////////////////////////////////////////////////////////////////////
+
+const CodeNotAcceptable = "NotAcceptable"
+
+func init() {
+	CodeStatus[CodeNotAcceptable] = http.StatusNotAcceptable
+}
+
+// 응답 인코더. Content-Type 은 등록된 미디어 타입으로 미리 설정되며 필요하면 덮어써도 됨
+type Encoder func(c *Context) error
+
+type encoderEntry struct {
+	mediaType string
+	encode    Encoder
+}
+
+// 등록 순서가 곧 우선순위. Accept 가 비었거나 */* 이면 첫번째 인코더 사용
+var encoders = []encoderEntry{
+	{"application/json", encodeJSON},
+	{"application/problem+json", encodeJSON},
+	{"text/html", encodeHTML},
+	{"application/xml", encodeXML},
+	{"text/xml", encodeXML},
+	{"text/csv", encodeCSV},
+	{"application/msgpack", encodeMsgPack},
+	{"application/x-msgpack", encodeMsgPack},
+	{"text/plain", encodeText},
+}
+
+/*
+앱 전용 미디어 타입 인코더 등록. 같은 타입이 있으면 교체
+기동 시(Initialize 등)에만 호출할것
+*/
+func RegisterEncoder(mediaType string, enc Encoder) {
+	mediaType = strings.ToLower(mediaType)
+	for i := range encoders {
+		if encoders[i].mediaType == mediaType {
+			encoders[i].encode = enc
+			return
+		}
+	}
+	encoders = append(encoders, encoderEntry{mediaType, enc})
+}
+
+type acceptRange struct {
+	typ, sub string
+	q        float64
+}
+
+func parseAccept(header string) []acceptRange {
+	var out []acceptRange
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		mt := strings.ToLower(strings.TrimSpace(fields[0]))
+		if mt == "" {
+			continue
+		}
+		typ, sub, ok := strings.Cut(mt, "/")
+		if !ok {
+			continue
+		}
+		q := 1.0
+		for _, p := range fields[1:] {
+			k, v, _ := strings.Cut(strings.TrimSpace(p), "=")
+			if strings.TrimSpace(k) == "q" {
+				if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+					q = f
+				}
+			}
+		}
+		out = append(out, acceptRange{typ, sub, q})
+	}
+	return out
+}
+
+// 미디어 타입에 대한 q 값과 구체성 (정확 일치 2, type/* 1, */* 0). 해당 없으면 q = -1
+func matchAccept(ranges []acceptRange, mediaType string) (float64, int) {
+	typ, sub, _ := strings.Cut(mediaType, "/")
+	q, spec := -1.0, -1
+	for _, r := range ranges {
+		s := -1
+		switch {
+		case r.typ == typ && r.sub == sub:
+			s = 2
+		case r.typ == typ && r.sub == "*":
+			s = 1
+		case r.typ == "*" && r.sub == "*":
+			s = 0
+		}
+		if s > spec {
+			q, spec = r.q, s
+		}
+	}
+	return q, spec
+}
+
+// Accept 헤더에 맞는 인코더 선택. 없으면 nil
+func negotiate(accept string) *encoderEntry {
+	if strings.TrimSpace(accept) == "" {
+		return &encoders[0]
+	}
+	ranges := parseAccept(accept)
+
+	var best *encoderEntry
+	bestQ, bestSpec := 0.0, -1
+	for i := range encoders {
+		q, spec := matchAccept(ranges, encoders[i].mediaType)
+		if q <= 0 {
+			continue
+		}
+		if q > bestQ || (q == bestQ && spec > bestSpec) {
+			best, bestQ, bestSpec = &encoders[i], q, spec
+		}
+	}
+	return best
+}
+
+/*
+Accept 헤더를 보고 응답 포맷 결정. 에러 응답은 포맷과 관계없이 StatusOf 상태코드
+a.Router.AddRoute(a, "GET", "/api/users", x.ReplyAuto, ListUsers)
+*/
+func ReplyAuto(c *Context) {
+	c.App.Logger.Debug("ReplyAuto")
+	addVary(c.Res.Header(), "Accept")
+
+	enc := negotiate(c.Req.Header.Get("Accept"))
+	if enc == nil {
+		c.AppError = NewAppError(CodeNotAcceptable, fmt.Errorf("no encoder for %q", c.Req.Header.Get("Accept")), nil)
+		c.Response.Code = c.AppError.Code
+		c.Response.Data = nil
+		c.Res.Header().Set("Content-Type", "application/json; charset=utf-8")
+		c.Res.WriteHeader(http.StatusNotAcceptable)
+		replyEnvelope(c)
+		return
+	}
+
+	c.Res.Header().Set("Content-Type", enc.mediaType+"; charset=utf-8")
+	if c.Response.Code != "OK" {
+		// 에러 상태코드는 포맷과 관계없이 여기서 한번만 (인코더의 WriteHeader 는 무시됨)
+		res := c.Res
+		c.Res = &statusWriter{ResponseWriter: res, status: StatusOf(c.Response.Code)}
+		defer func() { c.Res = res }()
+	}
+	if err := enc.encode(c); err != nil {
+		c.App.Logger.Error("ReplyAuto", enc.mediaType, err)
+	}
+}
+
+// 첫 WriteHeader/Write 때 정해진 상태코드를 씀. 인코더가 Content-Type 을 바꿀 수 있도록 늦게 씀
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+	wrote  bool
+}
+
+func (w *statusWriter) WriteHeader(int) {
+	if !w.wrote {
+		w.wrote = true
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	w.WriteHeader(0)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *statusWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+// /////////////////////////////////////////////////////////////////////////////
+// 기본 인코더
+// /////////////////////////////////////////////////////////////////////////////
+
+func encodeJSON(c *Context) error {
+	if c.Response.Code != "OK" && acceptsProblem(c.Req) {
+		ReplyProblem(c)
+		return nil
+	}
+	replyEnvelope(c)
+	return nil
+}
+
+func encodeHTML(c *Context) error {
+	ReplyHTML(c)
+	return nil
+}
+
+func encodeText(c *Context) error {
+	if c.Response.Code != "OK" {
+		_, err := fmt.Fprintf(c.Res, "%s\n", c.AppError.publicMessage())
+		return err
+	}
+	switch v := c.Response.Data.(type) {
+	case nil:
+		_, err := fmt.Fprintln(c.Res, c.Response.Code)
+		return err
+	case string:
+		_, err := io.WriteString(c.Res, v)
+		return err
+	case []byte:
+		_, err := c.Res.Write(v)
+		return err
+	default:
+		_, err := fmt.Fprintln(c.Res, v)
+		return err
+	}
+}
+
+// 임의의 값을 JSON 왕복으로 map/slice/json.Number 등 일반형으로 변환
+func toGeneric(v any) (any, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	var out any
+	err = d.Decode(&out)
+	return out, err
+}
+
+// 응답 봉투를 일반형으로
+func responseGeneric(c *Context) (map[string]any, error) {
+	data, err := toGeneric(c.Response.Data)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{
+		"Code":    c.Response.Code,
+		"Message": c.Response.Message,
+		"Data":    data,
+		"Elapsed": c.Response.Elapsed,
+	}, nil
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ----------------------------------------------------------------------------- XML
+
+func encodeXML(c *Context) error {
+	resp, err := responseGeneric(c)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	buf.WriteString("<Response>")
+	for _, k := range []string{"Code", "Message", "Data", "Elapsed"} {
+		writeXMLValue(&buf, k, resp[k])
+	}
+	buf.WriteString("</Response>")
+	_, err = c.Res.Write(buf.Bytes())
+	return err
+}
+
+func validXMLName(s string) bool {
+	if s == "" || strings.HasPrefix(strings.ToLower(s), "xml") {
+		return false
+	}
+	for i, r := range s {
+		letter := r == '_' || (r >= 'A' && r <= 'Z') || (r >= 'a' && r <= 'z') || r > 0x7f
+		if i == 0 && !letter {
+			return false
+		}
+		if !letter && !(r >= '0' && r <= '9') && r != '-' && r != '.' {
+			return false
+		}
+	}
+	return true
+}
+
+// 유효한 XML 이름이 아닌 키는 <item key="..."> 로 출력
+func writeXMLValue(buf *bytes.Buffer, name string, v any) {
+	if validXMLName(name) {
+		buf.WriteString("<" + name + ">")
+		defer buf.WriteString("</" + name + ">")
+	} else {
+		buf.WriteString(`<item key="`)
+		xml.EscapeText(buf, []byte(name))
+		buf.WriteString(`">`)
+		defer buf.WriteString("</item>")
+	}
+
+	switch t := v.(type) {
+	case nil:
+	case map[string]any:
+		for _, k := range sortedKeys(t) {
+			writeXMLValue(buf, k, t[k])
+		}
+	case []any:
+		for _, e := range t {
+			writeXMLValue(buf, "item", e)
+		}
+	default:
+		xml.EscapeText(buf, []byte(fmt.Sprint(t)))
+	}
+}
+
+// ----------------------------------------------------------------------------- CSV
+
+/*
+Data 가 객체 배열이면 키 합집합을 헤더로, 2차원 배열이면 그대로 출력.
+그 외에는 value 단일 컬럼. 에러 응답은 Code,Message 한 줄
+*/
+func encodeCSV(c *Context) error {
+	w := csv.NewWriter(c.Res)
+	defer w.Flush()
+
+	if c.Response.Code != "OK" {
+		w.Write([]string{"Code", "Message"})
+		return w.Write([]string{c.Response.Code, c.AppError.publicMessage()})
+	}
+
+	data, err := toGeneric(c.Response.Data)
+	if err != nil {
+		return err
+	}
+	rows, ok := data.([]any)
+	if !ok {
+		rows = []any{data}
+	}
+
+	var header []string
+	seen := map[string]bool{}
+	for _, r := range rows {
+		if m, ok := r.(map[string]any); ok {
+			for _, k := range sortedKeys(m) {
+				if !seen[k] {
+					seen[k] = true
+					header = append(header, k)
+				}
+			}
+		}
+	}
+
+	if header != nil {
+		w.Write(header)
+		for _, r := range rows {
+			m, _ := r.(map[string]any)
+			rec := make([]string, len(header))
+			for i, k := range header {
+				rec[i] = csvCell(m[k])
+			}
+			w.Write(rec)
+		}
+		return w.Error()
+	}
+
+	w.Write([]string{"value"})
+	for _, r := range rows {
+		if cols, ok := r.([]any); ok {
+			rec := make([]string, len(cols))
+			for i, v := range cols {
+				rec[i] = csvCell(v)
+			}
+			w.Write(rec)
+		} else {
+			w.Write([]string{csvCell(r)})
+		}
+	}
+	return w.Error()
+}
+
+func csvCell(v any) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case map[string]any, []any:
+		b, _ := json.Marshal(t)
+		return string(b)
+	default:
+		return fmt.Sprint(t)
+	}
+}
+
+// ----------------------------------------------------------------------------- MessagePack
+
+func encodeMsgPack(c *Context) error {
+	resp, err := responseGeneric(c)
+	if err != nil {
+		return err
+	}
+	c.Res.Header().Set("Content-Type", "application/msgpack")
+	var buf bytes.Buffer
+	writeMsgPack(&buf, resp)
+	_, err = c.Res.Write(buf.Bytes())
+	return err
+}
+
+// JSON 일반형(nil, bool, string, json.Number, []any, map[string]any)만 다루는 최소 구현
+func writeMsgPack(buf *bytes.Buffer, v any) {
+	switch t := v.(type) {
+	case nil:
+		buf.WriteByte(0xc0)
+	case bool:
+		if t {
+			buf.WriteByte(0xc3)
+		} else {
+			buf.WriteByte(0xc2)
+		}
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			writeMsgPackInt(buf, i)
+		} else {
+			f, _ := t.Float64()
+			buf.WriteByte(0xcb)
+			binary.Write(buf, binary.BigEndian, math.Float64bits(f))
+		}
+	case string:
+		n := len(t)
+		switch {
+		case n < 32:
+			buf.WriteByte(0xa0 | byte(n))
+		case n <= math.MaxUint8:
+			buf.WriteByte(0xd9)
+			buf.WriteByte(byte(n))
+		case n <= math.MaxUint16:
+			buf.WriteByte(0xda)
+			binary.Write(buf, binary.BigEndian, uint16(n))
+		default:
+			buf.WriteByte(0xdb)
+			binary.Write(buf, binary.BigEndian, uint32(n))
+		}
+		buf.WriteString(t)
+	case []any:
+		n := len(t)
+		switch {
+		case n < 16:
+			buf.WriteByte(0x90 | byte(n))
+		case n <= math.MaxUint16:
+			buf.WriteByte(0xdc)
+			binary.Write(buf, binary.BigEndian, uint16(n))
+		default:
+			buf.WriteByte(0xdd)
+			binary.Write(buf, binary.BigEndian, uint32(n))
+		}
+		for _, e := range t {
+			writeMsgPack(buf, e)
+		}
+	case map[string]any:
+		n := len(t)
+		switch {
+		case n < 16:
+			buf.WriteByte(0x80 | byte(n))
+		case n <= math.MaxUint16:
+			buf.WriteByte(0xde)
+			binary.Write(buf, binary.BigEndian, uint16(n))
+		default:
+			buf.WriteByte(0xdf)
+			binary.Write(buf, binary.BigEndian, uint32(n))
+		}
+		for _, k := range sortedKeys(t) {
+			writeMsgPack(buf, k)
+			writeMsgPack(buf, t[k])
+		}
+	default:
+		writeMsgPack(buf, fmt.Sprint(t))
+	}
+}
+
+func writeMsgPackInt(buf *bytes.Buffer, i int64) {
+	switch {
+	case i >= 0 && i < 128:
+		buf.WriteByte(byte(i))
+	case i < 0 && i >= -32:
+		buf.WriteByte(byte(int8(i)))
+	case i >= math.MinInt8 && i <= math.MaxInt8:
+		buf.WriteByte(0xd0)
+		buf.WriteByte(byte(int8(i)))
+	case i >= math.MinInt16 && i <= math.MaxInt16:
+		buf.WriteByte(0xd1)
+		binary.Write(buf, binary.BigEndian, int16(i))
+	case i >= math.MinInt32 && i <= math.MaxInt32:
+		buf.WriteByte(0xd2)
+		binary.Write(buf, binary.BigEndian, int32(i))
+	default:
+		buf.WriteByte(0xd3)
+		binary.Write(buf, binary.BigEndian, i)
+	}
+}
@@ -0,0 +1,150 @@
+package x
+
+import (
+	"bytes"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   string // "" 이면 406
+	}{
+		{"", "application/json"},
+		{"*/*", "application/json"},
+		{"text/csv", "text/csv"},
+		{"application/xml;q=0.5, text/csv;q=0.9", "text/csv"},
+		{"text/*", "text/html"},
+		{"text/*;q=0.5, text/plain", "text/plain"},
+		{"application/msgpack, */*;q=0.1", "application/msgpack"},
+		{"image/png", ""},
+		{"text/csv;q=0", ""},
+	}
+	for _, tt := range tests {
+		enc := negotiate(tt.accept)
+		got := ""
+		if enc != nil {
+			got = enc.mediaType
+		}
+		if got != tt.want {
+			t.Errorf("negotiate(%q) = %q, want %q", tt.accept, got, tt.want)
+		}
+	}
+}
+
+func newAutoApp(data any, err error) *App {
+	a := NewApp()
+	a.Router.AddRoute(a, "GET", "/auto", ReplyAuto, func(c *Context) {
+		if err != nil {
+			panic(err)
+		}
+		c.Response.Data = data
+	})
+	return a
+}
+
+func getAuto(a *App, accept string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest("GET", "/auto", nil)
+	r.Header.Set("Accept", accept)
+	w := httptest.NewRecorder()
+	a.Server.Handler.ServeHTTP(w, r)
+	return w
+}
+
+func TestReplyAutoErrors(t *testing.T) {
+	internal := newAutoApp(nil, errors.New("internal secret path /etc/x"))
+	notFound := newAutoApp(nil, NewAppError(CodeNotFound, errors.New("user 7"), nil))
+
+	tests := []struct {
+		app    *App
+		accept string
+		status int
+		want   string
+	}{
+		{internal, "text/plain", 500, "RuntimeError\n"},
+		{internal, "text/csv", 500, "Code,Message\nRuntimeError,RuntimeError\n"},
+		{internal, "application/json", 500, `"Code":"RuntimeError"`},
+		{internal, "application/xml", 500, "<Code>RuntimeError</Code>"},
+		{internal, "text/html", 500, "Error: RuntimeError"},
+		{internal, "application/problem+json", 500, `"status":500`},
+		{notFound, "text/plain", 404, "NotFound: user 7\n"},
+		{notFound, "text/csv", 404, "NotFound,NotFound: user 7\n"},
+		{notFound, "application/msgpack", 404, "NotFound"},
+		{notFound, "image/png", 406, `"Code":"NotAcceptable"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			w := getAuto(tt.app, tt.accept)
+			body := w.Body.String()
+			if w.Code != tt.status || !strings.Contains(body, tt.want) || strings.Contains(body, "secret") {
+				t.Fatalf("got %d %q, want %d containing %q", w.Code, body, tt.status, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodeCSV(t *testing.T) {
+	tests := []struct {
+		name string
+		data any
+		want string
+	}{
+		{"objects", []map[string]any{{"b": 2, "a": "x,y"}, {"c": true}},
+			"a,b,c\n\"x,y\",2,\n,,true\n"},
+		{"rows", [][]any{{1, "a"}, {2, []int{3}}}, "value\n1,a\n2,[3]\n"},
+		{"scalar", "hello", "value\nhello\n"},
+	}
+	for _, tt := range tests {
+		w := getAuto(newAutoApp(tt.data, nil), "text/csv")
+		if got := w.Body.String(); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestEncodeXML(t *testing.T) {
+	w := getAuto(newAutoApp(map[string]any{"a b": 1, "List": []any{"<x>", nil}}, nil), "application/xml")
+	want := `<Data><List><item>&lt;x&gt;</item><item></item></List><item key="a b">1</item></Data>`
+	if body := w.Body.String(); !strings.HasPrefix(body, "<?xml") || !strings.Contains(body, want) {
+		t.Fatalf("body %q, want %q", body, want)
+	}
+}
+
+func TestWriteMsgPack(t *testing.T) {
+	generic := func(v any) any {
+		g, err := toGeneric(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return g
+	}
+	tests := []struct {
+		name string
+		v    any
+		want []byte
+	}{
+		{"nil", nil, []byte{0xc0}},
+		{"bools", []any{true, false}, []byte{0x92, 0xc3, 0xc2}},
+		{"fixint", 5, []byte{0x05}},
+		{"negative fixint", -1, []byte{0xff}},
+		{"int8", -33, []byte{0xd0, 0xdf}},
+		{"int16", 200, []byte{0xd1, 0x00, 0xc8}},
+		{"int32", 70000, []byte{0xd2, 0x00, 0x01, 0x11, 0x70}},
+		{"int64", int64(1) << 40, []byte{0xd3, 0, 0, 0x01, 0, 0, 0, 0, 0}},
+		{"float", 1.5, []byte{0xcb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0}},
+		{"fixstr", "ab", []byte{0xa2, 'a', 'b'}},
+		{"str8", strings.Repeat("a", 40), append([]byte{0xd9, 40}, strings.Repeat("a", 40)...)},
+		{"map sorted", map[string]any{"b": 1, "a": nil}, []byte{0x82, 0xa1, 'a', 0xc0, 0xa1, 'b', 0x01}},
+		{"array16", make([]any, 16), append([]byte{0xdc, 0, 16}, bytes.Repeat([]byte{0xc0}, 16)...)},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		writeMsgPack(&buf, generic(tt.v))
+		if !bytes.Equal(buf.Bytes(), tt.want) {
+			t.Errorf("%s: % x, want % x", tt.name, buf.Bytes(), tt.want)
+		}
+	}
+}
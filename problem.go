@@ -0,0 +1,103 @@
+package x
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// RFC 7807 problem+json
+// /////////////////////////////////////////////////////////////////////////////
+
+// 에러 코드별 HTTP 상태. 없는 코드는 500
+var CodeStatus = map[string]int{
+	"OK":                 http.StatusOK,
+	"RuntimeError":       http.StatusInternalServerError,
+	CodeNotFound:         http.StatusNotFound,
+	CodeUnauthorized:     http.StatusUnauthorized,
+	CodeForbidden:        http.StatusForbidden,
+	CodeConflict:         http.StatusConflict,
+	CodeInvalidParameter: http.StatusBadRequest,
+	CodeTimeout:          http.StatusGatewayTimeout,
+}
+
+// 에러 코드별 제목. 없으면 HTTP 상태 문구 사용
+var CodeTitle = map[string]string{}
+
+func StatusOf(code string) int {
+	if s, ok := CodeStatus[code]; ok {
+		return s
+	}
+	return http.StatusInternalServerError
+}
+
+func titleOf(code string, status int) string {
+	if t, ok := CodeTitle[code]; ok {
+		return t
+	}
+	return http.StatusText(status)
+}
+
+// RFC 7807 표준 멤버 이름. Data 의 같은 키는 덮어쓰지 못함
+var problemReserved = map[string]bool{
+	"type": true, "title": true, "status": true, "detail": true, "instance": true,
+}
+
+// AppError 를 problem 문서로 변환
+func (c *Context) Problem() map[string]any {
+	e := c.AppError
+	status := StatusOf(e.Code)
+
+	doc := map[string]any{}
+	for k, v := range e.Data {
+		if !problemReserved[k] {
+			doc[k] = v
+		}
+	}
+	doc["type"] = c.App.ProblemBaseURI + e.Code
+	doc["title"] = titleOf(e.Code, status)
+	doc["status"] = status
+	doc["instance"] = c.ReqID
+	doc["code"] = e.Code
+	if e.Err != nil && !e.internal() {
+		doc["detail"] = e.Err.Error()
+	}
+	return doc
+}
+
+// 내부 에러는 메세지에 경로, 쿼리 등이 들어있을 수 있으므로 클라이언트에 보내지 않음 (로그와 Reporter 로만)
+func (e *AppError) internal() bool {
+	return e.Code == "RuntimeError"
+}
+
+// 클라이언트에 보여줄 에러 메세지. 내부 에러는 코드만
+func (e *AppError) publicMessage() string {
+	if e.internal() {
+		return e.Code
+	}
+	return e.Error()
+}
+
+// 클라이언트가 problem+json 을 받을 수 있는지
+func acceptsProblem(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "application/problem+json")
+}
+
+/*
+에러를 RFC 7807 문서로 응답. 성공 응답은 ReplyJSON 과 동일
+a.Router.AddRoute(a, "GET", "/api/user", x.ReplyProblem, GetUser)
+*/
+func ReplyProblem(c *Context) {
+	c.App.Logger.Debug("ReplyProblem")
+	if c.Response.Code == "OK" {
+		replyEnvelope(c)
+		return
+	}
+
+	c.Res.Header().Set("Content-Type", "application/problem+json; charset=utf-8")
+	c.Res.WriteHeader(StatusOf(c.Response.Code))
+	if err := json.NewEncoder(c.Res).Encode(c.Problem()); err != nil {
+		c.App.Logger.Error("ReplyProblem", err)
+	}
+}
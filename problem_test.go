@@ -0,0 +1,46 @@
+package x
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestErrorDetailExposure(t *testing.T) {
+	a := NewApp()
+	a.Templates = NewTemplates(fstest.MapFS{
+		"views/errors/default.html": {Data: []byte(`<p>{{.Error}}</p>`)},
+	}, "views")
+	if err := a.Templates.Load(); err != nil {
+		t.Fatal(err)
+	}
+	internal := func(c *Context) { panic(errors.New("dial tcp 10.0.0.5:3306: secret")) }
+	forbidden := func(c *Context) { NewAppError(CodeForbidden, errors.New("not your order"), nil).Panic() }
+	a.Router.AddRoute(a, "GET", "/problem/internal", ReplyProblem, internal)
+	a.Router.AddRoute(a, "GET", "/problem/forbidden", ReplyProblem, forbidden)
+	a.Router.AddRoute(a, "GET", "/html/internal", ReplyHTML, internal)
+	a.Router.AddRoute(a, "GET", "/html/forbidden", ReplyHTML, forbidden)
+
+	tests := []struct {
+		path string
+		want string
+		hide string
+	}{
+		{"/problem/internal", `"code":"RuntimeError"`, "secret"},
+		{"/problem/forbidden", `"detail":"not your order"`, ""},
+		{"/html/internal", "<p>RuntimeError</p>", "secret"},
+		{"/html/forbidden", "not your order", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			a.Server.Handler.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+			body := w.Body.String()
+			if !strings.Contains(body, tt.want) || (tt.hide != "" && strings.Contains(body, tt.hide)) {
+				t.Fatalf("body %q, want %q without %q", body, tt.want, tt.hide)
+			}
+		})
+	}
+}
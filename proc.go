@@ -0,0 +1,323 @@
+package x
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// 저장 프로시저 호출 (SPI/SPS/SPU/SPD 규칙)
+// /////////////////////////////////////////////////////////////////////////////
+/*
+이름 접두사로 호출 방식을 정함
+  - SPI_, SPU_, SPD_ : Exec. RowsAffected, LastInsertID 채움 (결과셋은 버림)
+  - SPS_ 및 그 외    : Query. 모든 결과셋을 Sets 에 담음
+
+	// 위치 인자
+	r := c.Call("db1", "SPU_address_book", id, name, age, phone, address)
+	// 이름 인자 (p_ 접두사 생략 가능, 없는 인자는 NULL)
+	r := c.Call("db1", "SPI_address_book", x.ProcArgs{"name": "김철수", "age": 32})
+	// 조회
+	c.Response.Data = c.Call("db1", "SPS_address_book_all").Rows()
+
+c.Tx(key) 로 시작한 트랜잭션이 있으면 그 안에서 호출됨
+*/
+
+// 이름 있는 인자. 이름은 프로시저 파라미터 이름 (p_ 접두사 생략 가능)
+type ProcArgs map[string]any
+
+type ProcResult struct {
+	Sets         [][]map[string]any
+	RowsAffected int64
+	LastInsertID int64
+}
+
+// 첫 번째 결과셋. 없으면 빈 슬라이스 (JSON 으로 [] 가 나감)
+func (r *ProcResult) Rows() []map[string]any {
+	if len(r.Sets) == 0 || r.Sets[0] == nil {
+		return []map[string]any{}
+	}
+	return r.Sets[0]
+}
+
+/*
+i 번째 결과셋을 dest 로 변환. dest 는 *[]T 또는 *T (첫 행)
+컬럼은 json 태그(또는 대소문자 무시한 필드 이름)로 매칭
+*/
+func (r *ProcResult) Scan(i int, dest any) error {
+	if i >= len(r.Sets) {
+		return fmt.Errorf("result set %d not found (%d sets)", i, len(r.Sets))
+	}
+	rv := reflect.ValueOf(dest)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return errors.New("dest must be a non-nil pointer")
+	}
+	var src any = r.Sets[i]
+	if rv.Elem().Kind() != reflect.Slice {
+		// 단일 행
+		if len(r.Sets[i]) == 0 {
+			return sql.ErrNoRows
+		}
+		src = r.Sets[i][0]
+	}
+	b, err := json.Marshal(src)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, dest)
+}
+
+var procNameRe = regexp.MustCompile(`^[A-Za-z0-9_$]+(\.[A-Za-z0-9_$]+)?$`)
+
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// 프로시저 파라미터 이름 캐시 (커넥션 + 이름)
+var procParams sync.Map
+
+type procKey struct {
+	db   *sql.DB
+	name string
+}
+
+func lookupProcParams(ctx context.Context, db *sql.DB, name string) ([]string, error) {
+	key := procKey{db, name}
+	if v, ok := procParams.Load(key); ok {
+		return v.([]string), nil
+	}
+
+	schema, proc, ok := strings.Cut(name, ".")
+	query := "SELECT PARAMETER_NAME FROM information_schema.PARAMETERS " +
+		"WHERE SPECIFIC_SCHEMA = DATABASE() AND SPECIFIC_NAME = ? AND ROUTINE_TYPE = 'PROCEDURE' " +
+		"AND ORDINAL_POSITION > 0 ORDER BY ORDINAL_POSITION"
+	args := []any{schema}
+	if ok {
+		query = strings.Replace(query, "DATABASE()", "?", 1)
+		args = []any{schema, proc}
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var names []string
+	for rows.Next() {
+		var n string
+		if err := rows.Scan(&n); err != nil {
+			return nil, err
+		}
+		names = append(names, n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	procParams.Store(key, names)
+	return names, nil
+}
+
+// 이름 인자를 파라미터 순서대로 정렬
+func bindProcArgs(params []string, named ProcArgs) ([]any, error) {
+	used := 0
+	args := make([]any, len(params))
+	for i, p := range params {
+		short := strings.TrimPrefix(p, "p_")
+		for k, v := range named {
+			if strings.EqualFold(k, p) || strings.EqualFold(k, short) {
+				args[i] = v
+				used++
+				break
+			}
+		}
+	}
+	if used != len(named) {
+		return nil, NewAppError(CodeInvalidParameter,
+			fmt.Errorf("unknown procedure arguments (params: %v)", params), nil)
+	}
+	return args, nil
+}
+
+func callProc(ctx context.Context, q querier, db *sql.DB, name string, args []any) (*ProcResult, error) {
+	if !procNameRe.MatchString(name) {
+		return nil, fmt.Errorf("invalid procedure name: %q", name)
+	}
+	if len(args) == 1 {
+		if named, ok := args[0].(ProcArgs); ok {
+			params, err := lookupProcParams(ctx, db, name)
+			if err != nil {
+				return nil, err
+			}
+			if args, err = bindProcArgs(params, named); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	quoted := "`" + strings.ReplaceAll(name, ".", "`.`") + "`"
+	stmt := "CALL " + quoted + "(" + strings.TrimSuffix(strings.Repeat("?, ", len(args)), ", ") + ")"
+
+	proc := name[strings.LastIndex(name, ".")+1:]
+	res := &ProcResult{}
+	switch {
+	case strings.HasPrefix(proc, "SPI_"), strings.HasPrefix(proc, "SPU_"), strings.HasPrefix(proc, "SPD_"):
+		r, err := q.ExecContext(ctx, stmt, args...)
+		if err != nil {
+			return nil, procError(name, err)
+		}
+		res.RowsAffected, _ = r.RowsAffected()
+		res.LastInsertID, _ = r.LastInsertId()
+	default:
+		rows, err := q.QueryContext(ctx, stmt, args...)
+		if err != nil {
+			return nil, procError(name, err)
+		}
+		defer rows.Close()
+		for {
+			set, err := scanMaps(rows)
+			if err != nil {
+				return nil, procError(name, err)
+			}
+			res.Sets = append(res.Sets, set)
+			if !rows.NextResultSet() {
+				break
+			}
+		}
+		if err := rows.Err(); err != nil {
+			return nil, procError(name, err)
+		}
+	}
+	return res, nil
+}
+
+// 현재 결과셋을 []map 으로. 텍스트 프로토콜의 []byte 는 컬럼 타입에 맞게 변환
+func scanMaps(rows *sql.Rows) ([]map[string]any, error) {
+	cols, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+	out := []map[string]any{}
+	vals := make([]any, len(cols))
+	ptrs := make([]any, len(cols))
+	for i := range vals {
+		ptrs[i] = &vals[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+		row := make(map[string]any, len(cols))
+		for i, ct := range cols {
+			row[ct.Name()] = convertColumn(ct.DatabaseTypeName(), vals[i])
+		}
+		out = append(out, row)
+	}
+	return out, rows.Err()
+}
+
+func convertColumn(typ string, v any) any {
+	b, ok := v.([]byte)
+	if !ok {
+		return v
+	}
+	s := string(b)
+	switch strings.TrimPrefix(typ, "UNSIGNED ") {
+	case "TINYINT", "SMALLINT", "MEDIUMINT", "INT", "BIGINT", "YEAR":
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return n
+		}
+		if n, err := strconv.ParseUint(s, 10, 64); err == nil {
+			return n
+		}
+	case "FLOAT", "DOUBLE":
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f
+		}
+	case "BINARY", "VARBINARY", "BLOB", "TINYBLOB", "MEDIUMBLOB", "LONGBLOB", "BIT":
+		return b
+	}
+	// DECIMAL 은 정밀도 유지를 위해 문자열
+	return s
+}
+
+/*
+SIGNAL 로 발생시킨 에러를 AppError 로 변환
+MESSAGE_TEXT 가 등록된 에러 코드(CodeStatus)로 시작하면 그 코드 사용. ":" 뒤는 상세 메시지
+
+	SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'NotFound: address not found';
+
+그 외에는 SignalCodes[SQLSTATE], 없으면 SignalDefaultCode
+중복 키(1062)는 Conflict
+*/
+var (
+	SignalCodes       = map[string]string{}
+	SignalDefaultCode = CodeInvalidParameter
+)
+
+const (
+	mysqlErrDupEntry = 1062
+	mysqlErrSignal   = 1644
+)
+
+func procError(name string, err error) error {
+	var me *mysql.MySQLError
+	if !errors.As(err, &me) {
+		return err
+	}
+	data := map[string]any{"Procedure": name}
+	if me.Number == mysqlErrDupEntry {
+		return NewAppError(CodeConflict, err, data)
+	}
+	if me.Number != mysqlErrSignal {
+		return err
+	}
+
+	code, detail, _ := strings.Cut(me.Message, ":")
+	code = strings.TrimSpace(code)
+	if _, ok := CodeStatus[code]; ok && code != "OK" {
+		data["Detail"] = strings.TrimSpace(detail)
+		return NewAppError(code, err, data)
+	}
+	data["Detail"] = me.Message
+	if c, ok := SignalCodes[string(me.SQLState[:])]; ok {
+		return NewAppError(c, err, data)
+	}
+	return NewAppError(SignalDefaultCode, err, data)
+}
+
+// 프로시저 호출. SIGNAL 에러는 *AppError 로 반환
+func (a *App) Call(ctx context.Context, key, name string, args ...any) (*ProcResult, error) {
+	db := a.GetConn(key)
+	if db == nil {
+		return nil, fmt.Errorf("unknown connection: %s", key)
+	}
+	return callProc(ctx, db, db, name, args)
+}
+
+// 핸들러용. 실패하면 panic (AppError 는 그대로 응답 코드가 됨)
+func (c *Context) Call(key, name string, args ...any) *ProcResult {
+	db := c.App.GetConn(key)
+	if db == nil {
+		panic(fmt.Errorf("unknown connection: %s", key))
+	}
+	var q querier = db
+	if tx, ok := c.txs[key]; ok {
+		q = tx
+	}
+	res, err := callProc(c.Req.Context(), q, db, name, args)
+	if err != nil {
+		panic(err)
+	}
+	return res
+}
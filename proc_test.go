@@ -0,0 +1,136 @@
+package x
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func signalError(state, msg string) *mysql.MySQLError {
+	e := &mysql.MySQLError{Number: mysqlErrSignal, Message: msg}
+	copy(e.SQLState[:], state)
+	return e
+}
+
+func TestProcError(t *testing.T) {
+	SignalCodes["45001"] = CodeForbidden
+	defer delete(SignalCodes, "45001")
+
+	plain := errors.New("connection refused")
+	other := &mysql.MySQLError{Number: 1146, Message: "table doesn't exist"}
+
+	tests := []struct {
+		name   string
+		err    error
+		code   string // "" 이면 원래 에러 그대로
+		detail string
+	}{
+		{"registered code", signalError("45000", "NotFound: address not found"), CodeNotFound, "address not found"},
+		{"code without detail", signalError("45000", "Conflict"), CodeConflict, ""},
+		{"sqlstate mapping", signalError("45001", "not your address"), CodeForbidden, "not your address"},
+		{"default code", signalError("45000", "age must be positive"), SignalDefaultCode, "age must be positive"},
+		{"OK is not an error code", signalError("45000", "OK: done"), SignalDefaultCode, "OK: done"},
+		{"wrapped signal", fmt.Errorf("call: %w", signalError("45000", "NotFound: x")), CodeNotFound, "x"},
+		{"duplicate key", &mysql.MySQLError{Number: mysqlErrDupEntry, Message: "Duplicate entry"}, CodeConflict, ""},
+		{"other mysql error", other, "", ""},
+		{"not a mysql error", plain, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := procError("SPU_address", tt.err)
+			var appErr *AppError
+			if tt.code == "" {
+				if err != tt.err || errors.As(err, &appErr) {
+					t.Fatalf("err %v changed", err)
+				}
+				return
+			}
+			if !errors.As(err, &appErr) || appErr.Code != tt.code {
+				t.Fatalf("err %v, want code %s", err, tt.code)
+			}
+			if appErr.Data["Procedure"] != "SPU_address" || (tt.detail != "" && appErr.Data["Detail"] != tt.detail) {
+				t.Fatalf("data %v", appErr.Data)
+			}
+			if !errors.Is(err, tt.err) {
+				t.Fatal("cause not wrapped")
+			}
+		})
+	}
+}
+
+func TestCallSignal(t *testing.T) {
+	stub, db := newStubDB()
+	stub.execErr = signalError("45000", "NotFound: address not found")
+	a := NewApp()
+	a.Conns["db"] = db
+	a.Router.AddRoute(a, "POST", "/address", ReplyJSON, func(c *Context) {
+		c.Tx("db")
+		c.Call("db", "SPU_address", 1, "new address")
+	})
+
+	body := serve(a, httptest.NewRequest("POST", "/address", nil))
+	if !strings.Contains(body, `"Code":"NotFound"`) {
+		t.Fatalf("body %q", body)
+	}
+	want := []string{"begin", "exec (tx) CALL `SPU_address`(?, ?) 2", "rollback"}
+	if log := stub.Log(); !slices.Equal(log, want) {
+		t.Fatalf("log %q, want %q", log, want)
+	}
+}
+
+func TestCall(t *testing.T) {
+	stub, db := newStubDB()
+	a := NewApp()
+	a.Conns["db"] = db
+
+	// 조회: 모든 결과셋을 컬럼 타입에 맞게 변환
+	stub.sets = []stubSet{
+		{[]string{"id", "name", "price"}, []string{"INT", "VARCHAR", "DECIMAL"}, [][]driver.Value{
+			{[]byte("1"), []byte("kim"), []byte("10.50")},
+			{[]byte("2"), []byte("lee"), []byte("3.00")},
+		}},
+		{[]string{"total"}, []string{"UNSIGNED BIGINT"}, [][]driver.Value{{[]byte("2")}}},
+	}
+	res, err := a.Call(t.Context(), "db", "SPS_address_list", "k")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Sets) != 2 || res.Rows()[0]["id"] != int64(1) || res.Rows()[1]["price"] != "3.00" || res.Sets[1][0]["total"] != int64(2) {
+		t.Fatalf("sets %v", res.Sets)
+	}
+	var rows []struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+	if err := res.Scan(0, &rows); err != nil || len(rows) != 2 || rows[1].Name != "lee" {
+		t.Fatalf("Scan = %+v, %v", rows, err)
+	}
+
+	// 변경: Exec 결과와 이름 인자 정렬
+	stub.sets = []stubSet{{[]string{"PARAMETER_NAME"}, []string{"VARCHAR"}, [][]driver.Value{
+		{[]byte("p_name")}, {[]byte("p_age")},
+	}}}
+	stub.affected = 1
+	res, err = a.Call(t.Context(), "db", "SPI_address_named", ProcArgs{"age": 32, "p_name": "kim"})
+	if err != nil || res.RowsAffected != 1 {
+		t.Fatalf("RowsAffected = %v, %v", res, err)
+	}
+	if log := stub.Log(); log[len(log)-1] != "exec CALL `SPI_address_named`(?, ?) 2" {
+		t.Fatalf("log %q", log)
+	}
+	_, err = a.Call(t.Context(), "db", "SPI_address_named", ProcArgs{"nope": 1})
+	var appErr *AppError
+	if !errors.As(err, &appErr) || appErr.Code != CodeInvalidParameter {
+		t.Fatalf("unknown argument: %v", err)
+	}
+
+	if _, err := a.Call(t.Context(), "db", "SPS_x; DROP TABLE t"); err == nil {
+		t.Fatal("invalid procedure name accepted")
+	}
+}
@@ -0,0 +1,224 @@
+package x
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// 신뢰 프록시 / 클라이언트 IP 결정
+// /////////////////////////////////////////////////////////////////////////////
+
+// App.ProxyHeader 값. 프록시가 실제로 덮어쓰거나 덧붙이는 헤더 하나만 지정할것
+// (예: nginx 기본 설정은 X-Forwarded-For 에 덧붙이기만 하고 Forwarded 는 그대로 전달함)
+const (
+	ProxyHeaderXForwardedFor = "X-Forwarded-For" // X-Forwarded-Proto/Host 도 함께 사용
+	ProxyHeaderForwarded     = "Forwarded"       // RFC 7239
+	ProxyHeaderXRealIP       = "X-Real-IP"
+	ProxyHeaderCFConnecting  = "CF-Connecting-IP"
+)
+
+/*
+신뢰할 프록시 대역 설정. 단일 IP 도 가능 ("10.0.0.0/8", "127.0.0.1", "::1")
+여기 없는 피어가 보낸 프록시 헤더는 무시됨. 어떤 헤더를 볼지는 App.ProxyHeader
+*/
+func (a *App) SetTrustedProxies(cidrs ...string) error {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, s := range cidrs {
+		if !strings.Contains(s, "/") {
+			ip := net.ParseIP(s)
+			if ip == nil {
+				return &net.ParseError{Type: "IP address", Text: s}
+			}
+			bits := 128
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, n, err := net.ParseCIDR(s)
+		if err != nil {
+			return err
+		}
+		nets = append(nets, n)
+	}
+	a.TrustedProxies = nets
+	return nil
+}
+
+func (a *App) isTrustedProxy(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	for _, n := range a.TrustedProxies {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// 프록시 헤더 한 구간 (Forwarded 요소 또는 X-Forwarded-For 항목)
+type forwardedHop struct {
+	ip    string
+	proto string
+	host  string
+}
+
+/*
+클라이언트 IP, 스킴, 호스트 결정
+직접 연결한 피어가 신뢰 프록시일 때만 App.ProxyHeader 하나를 보고, 오른쪽부터 신뢰 프록시를 건너뛰어
+처음 나오는 신뢰할 수 없는 주소를 클라이언트로 봄
+클라이언트가 다른 프록시 헤더를 직접 보낼 수 있으므로 헤더 사이에 대체(fallback)는 하지 않음
+*/
+func (c *Context) resolveClient() {
+	r := c.Req
+	peer, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		peer = r.RemoteAddr
+	}
+
+	c.RemoteIP = peer
+	c.Scheme = "http"
+	if r.TLS != nil {
+		c.Scheme = "https"
+	}
+	c.Host = r.Host
+
+	if !c.App.isTrustedProxy(net.ParseIP(peer)) {
+		return
+	}
+
+	var hops []forwardedHop
+	switch c.App.ProxyHeader {
+	case ProxyHeaderForwarded:
+		hops = parseForwarded(strings.Join(r.Header.Values("Forwarded"), ","))
+	case ProxyHeaderXForwardedFor:
+		hops = parseXForwarded(r.Header)
+	case ProxyHeaderXRealIP, ProxyHeaderCFConnecting:
+		if v := strings.TrimSpace(r.Header.Get(c.App.ProxyHeader)); net.ParseIP(v) != nil {
+			c.RemoteIP = v
+		}
+		return
+	default:
+		return
+	}
+
+	// 오른쪽부터 신뢰 프록시 건너뛰기. 전부 신뢰 프록시면 가장 왼쪽
+	var client *forwardedHop
+	for i := len(hops) - 1; i >= 0; i-- {
+		client = &hops[i]
+		if !c.App.isTrustedProxy(net.ParseIP(hops[i].ip)) {
+			break
+		}
+	}
+	if client == nil || net.ParseIP(client.ip) == nil {
+		return
+	}
+
+	c.RemoteIP = client.ip
+	if client.proto == "http" || client.proto == "https" {
+		c.Scheme = client.proto
+	}
+	if client.host != "" {
+		c.Host = client.host
+	}
+}
+
+/*
+X-Forwarded-For 와 X-Forwarded-Proto/Host 조합
+Proto/Host 는 가장 오른쪽 값 (가까운 신뢰 프록시가 쓴 값. 왼쪽은 클라이언트가 보낸 값일 수 있음)
+*/
+func parseXForwarded(h http.Header) []forwardedHop {
+	proto := lastListValue(h.Values("X-Forwarded-Proto"))
+	host := lastListValue(h.Values("X-Forwarded-Host"))
+
+	var hops []forwardedHop
+	for _, v := range h.Values("X-Forwarded-For") {
+		for _, ip := range strings.Split(v, ",") {
+			ip = strings.TrimSpace(ip)
+			if ip != "" {
+				hops = append(hops, forwardedHop{ip: ip, proto: strings.ToLower(proto), host: host})
+			}
+		}
+	}
+	return hops
+}
+
+func lastListValue(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	s := values[len(values)-1]
+	return strings.TrimSpace(s[strings.LastIndex(s, ",")+1:])
+}
+
+/*
+RFC 7239 Forwarded 헤더 파싱
+Forwarded: for=192.0.2.60;proto=https;host=example.com, for="[2001:db8::1]:4711"
+*/
+func parseForwarded(s string) []forwardedHop {
+	var hops []forwardedHop
+	for _, elem := range splitQuoted(s, ',') {
+		var hop forwardedHop
+		for _, pair := range splitQuoted(elem, ';') {
+			k, v, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			if !ok {
+				continue
+			}
+			v = strings.TrimSpace(v)
+			if len(v) >= 2 && v[0] == '"' && v[len(v)-1] == '"' {
+				v = strings.ReplaceAll(v[1:len(v)-1], `\"`, `"`)
+			}
+			switch strings.ToLower(strings.TrimSpace(k)) {
+			case "for":
+				hop.ip = forwardedNodeIP(v)
+			case "proto":
+				hop.proto = strings.ToLower(v)
+			case "host":
+				hop.host = v
+			}
+		}
+		hops = append(hops, hop)
+	}
+	return hops
+}
+
+// node 값에서 IP 만 추출. unknown 이나 _난독화 식별자는 빈 문자열
+func forwardedNodeIP(v string) string {
+	if strings.HasPrefix(v, "[") {
+		if end := strings.Index(v, "]"); end > 0 {
+			return v[1:end]
+		}
+		return ""
+	}
+	if host, _, err := net.SplitHostPort(v); err == nil {
+		v = host
+	}
+	if net.ParseIP(v) == nil {
+		return ""
+	}
+	return v
+}
+
+// 따옴표 안의 구분자는 무시하고 분리
+func splitQuoted(s string, sep byte) []string {
+	var out []string
+	inQuote, escaped, start := false, false, 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case escaped:
+			escaped = false
+		case s[i] == '\\' && inQuote:
+			escaped = true
+		case s[i] == '"':
+			inQuote = !inQuote
+		case s[i] == sep && !inQuote:
+			out = append(out, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(out, s[start:])
+}
@@ -0,0 +1,97 @@
+package x
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestResolveClient(t *testing.T) {
+	tests := []struct {
+		name    string
+		mode    string
+		peer    string
+		headers map[string][]string
+		ip      string
+		scheme  string
+		host    string
+	}{
+		{
+			name:    "untrusted peer ignores headers",
+			peer:    "203.0.113.9:1234",
+			headers: map[string][]string{"X-Forwarded-For": {"1.2.3.4"}},
+			ip:      "203.0.113.9", scheme: "http", host: "example.com",
+		},
+		{
+			name:    "xff skips trusted hops from the right",
+			peer:    "10.0.0.1:1234",
+			headers: map[string][]string{"X-Forwarded-For": {"1.2.3.4, 198.51.100.7, 10.0.0.2"}},
+			ip:      "198.51.100.7", scheme: "http", host: "example.com",
+		},
+		{
+			name: "forwarded passed through is ignored in xff mode",
+			peer: "10.0.0.1:1234",
+			headers: map[string][]string{
+				"Forwarded":       {"for=1.2.3.4"},
+				"X-Forwarded-For": {"198.51.100.7"},
+			},
+			ip: "198.51.100.7", scheme: "http", host: "example.com",
+		},
+		{
+			name:    "no fallback to forwarded when xff is missing",
+			peer:    "10.0.0.1:1234",
+			headers: map[string][]string{"Forwarded": {"for=1.2.3.4"}},
+			ip:      "10.0.0.1", scheme: "http", host: "example.com",
+		},
+		{
+			name: "proto and host use the rightmost value",
+			peer: "10.0.0.1:1234",
+			headers: map[string][]string{
+				"X-Forwarded-For":   {"198.51.100.7"},
+				"X-Forwarded-Proto": {"http, https"},
+				"X-Forwarded-Host":  {"evil.example, good.example"},
+			},
+			ip: "198.51.100.7", scheme: "https", host: "good.example",
+		},
+		{
+			name: "forwarded mode",
+			mode: ProxyHeaderForwarded,
+			peer: "10.0.0.1:1234",
+			headers: map[string][]string{
+				"Forwarded":       {`for=1.2.3.4, for="[2001:db8::1]:4711";proto=https;host=good.example`},
+				"X-Forwarded-For": {"5.6.7.8"},
+			},
+			ip: "2001:db8::1", scheme: "https", host: "good.example",
+		},
+		{
+			name: "x-real-ip mode ignores xff",
+			mode: ProxyHeaderXRealIP,
+			peer: "10.0.0.1:1234",
+			headers: map[string][]string{
+				"X-Real-Ip":       {"198.51.100.7"},
+				"X-Forwarded-For": {"1.2.3.4"},
+			},
+			ip: "198.51.100.7", scheme: "http", host: "example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewApp()
+			if err := a.SetTrustedProxies("10.0.0.0/8"); err != nil {
+				t.Fatal(err)
+			}
+			if tt.mode != "" {
+				a.ProxyHeader = tt.mode
+			}
+			r := httptest.NewRequest("GET", "http://example.com/", nil)
+			r.RemoteAddr = tt.peer
+			for k, v := range tt.headers {
+				r.Header[k] = v
+			}
+			c := NewContext(a, httptest.NewRecorder(), r)
+			if c.RemoteIP != tt.ip || c.Scheme != tt.scheme || c.Host != tt.host {
+				t.Fatalf("got %s %s %s, want %s %s %s", c.RemoteIP, c.Scheme, c.Host, tt.ip, tt.scheme, tt.host)
+			}
+		})
+	}
+}
@@ -0,0 +1,322 @@
+package x
+
+import (
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// 레이트 리밋
+// /////////////////////////////////////////////////////////////////////////////
+
+const CodeTooManyRequests = "TooManyRequests"
+
+func init() {
+	CodeStatus[CodeTooManyRequests] = http.StatusTooManyRequests
+}
+
+// 키별 리밋 상태. 알고리즘마다 필드 의미가 다름
+//   - TokenBucket   : A = 남은 토큰, T = 마지막 충전 시각(ns)
+//   - SlidingWindow : A = 이전 윈도우 카운트, B = 현재 윈도우 카운트, T = 현재 윈도우 시작(ns)
+type LimitState struct {
+	A, B float64
+	T    int64
+}
+
+/*
+상태 저장소. Update 는 key 의 상태를 읽어 fn 으로 고친 뒤 저장하는 과정을 원자적으로 수행해야 함
+ttl 이 지난 상태는 버려도 됨
+*/
+type LimitStore interface {
+	Update(key string, ttl time.Duration, fn func(s *LimitState)) error
+}
+
+type Algorithm int
+
+const (
+	TokenBucket Algorithm = iota
+	SlidingWindow
+)
+
+type Limiter struct {
+	Name      string // 키 접두사. 여러 리미터가 같은 저장소를 쓸 때 구분용
+	Algorithm Algorithm
+	Limit     int           // Window 당 허용 요청 수 (토큰버킷은 버킷 크기)
+	Window    time.Duration // 토큰버킷은 Limit 개가 다시 차는 시간
+	Key       func(c *Context) string
+	Store     LimitStore
+}
+
+/*
+리미터 생성. 키 함수 기본값은 KeyByIP, 저장소 기본값은 메모리
+l := x.NewLimiter("api", x.TokenBucket, 100, time.Minute)
+a.Router.AddPreprocessors(l.Handler)                      // 전역
+a.Router.AddRoute(a, "POST", "/login", x.ReplyJSON, l.Handler, Login) // 라우트별
+*/
+func NewLimiter(name string, alg Algorithm, limit int, window time.Duration) *Limiter {
+	return &Limiter{
+		Name:      name,
+		Algorithm: alg,
+		Limit:     limit,
+		Window:    window,
+		Key:       KeyByIP,
+		Store:     NewMemoryLimitStore(),
+	}
+}
+
+// 키 함수들
+func KeyByIP(c *Context) string { return c.RemoteIP }
+
+// 전역 전처리기에서는 c.Route 가 아직 없으므로 라우터와 같은 방식으로 찾음
+func KeyByRoute(c *Context) string {
+	route := c.Route
+	if route == nil {
+		route = c.App.Router.findRoute(c.Req.Method, c.Req.URL.Path)
+	}
+	if route != nil {
+		return route.Method + " " + route.Path
+	}
+	// 없는 라우트도 "//a", "/a/" 가 같은 키가 되도록 findRoute 처럼 정규화
+	return c.Req.Method + " /" + strings.Trim(c.Req.URL.Path, "/")
+}
+
+// 라우트 + IP 조합
+func KeyByRouteIP(c *Context) string { return KeyByRoute(c) + "|" + c.RemoteIP }
+
+// 앞단 핸들러가 c.Set 으로 넣어둔 사용자 식별값 기준. 없으면 IP
+func KeyByStore(name string) func(c *Context) string {
+	return func(c *Context) string {
+		if v := c.Get(name); v != nil {
+			return fmt.Sprint(v)
+		}
+		return c.RemoteIP
+	}
+}
+
+type LimitResult struct {
+	Allowed    bool
+	Remaining  int
+	Reset      time.Duration // 한도가 완전히 회복될 때까지
+	RetryAfter time.Duration // 거부된 경우 다시 시도 가능한 시점까지
+}
+
+func (l *Limiter) Take(key string, now time.Time) (LimitResult, error) {
+	var res LimitResult
+	err := l.Store.Update(l.Name+"|"+key, 2*l.Window, func(s *LimitState) {
+		switch l.Algorithm {
+		case SlidingWindow:
+			res = l.slidingWindow(s, now)
+		default:
+			res = l.tokenBucket(s, now)
+		}
+	})
+	return res, err
+}
+
+func (l *Limiter) tokenBucket(s *LimitState, now time.Time) LimitResult {
+	capacity := float64(l.Limit)
+	rate := capacity / float64(l.Window) // 토큰/ns
+
+	if s.T == 0 {
+		s.A = capacity
+	} else {
+		s.A = math.Min(capacity, s.A+float64(now.UnixNano()-s.T)*rate)
+	}
+	s.T = now.UnixNano()
+
+	res := LimitResult{}
+	if s.A >= 1 {
+		s.A--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = time.Duration((1 - s.A) / rate)
+	}
+	res.Remaining = int(s.A)
+	res.Reset = time.Duration((capacity - s.A) / rate)
+	return res
+}
+
+// 이전 윈도우 카운트를 경과 비율만큼 가중한 근사 슬라이딩 윈도우
+func (l *Limiter) slidingWindow(s *LimitState, now time.Time) LimitResult {
+	w := int64(l.Window)
+	start := now.UnixNano() - now.UnixNano()%w
+
+	switch {
+	case s.T == start:
+	case s.T == start-w:
+		s.A, s.B, s.T = s.B, 0, start
+	default:
+		s.A, s.B, s.T = 0, 0, start
+	}
+
+	elapsed := float64(now.UnixNano()-start) / float64(w)
+	estimate := s.A*(1-elapsed) + s.B
+
+	res := LimitResult{Reset: time.Duration(start + w - now.UnixNano())}
+	if estimate+1 <= float64(l.Limit) {
+		s.B++
+		estimate++
+		res.Allowed = true
+	} else {
+		res.RetryAfter = res.Reset
+	}
+	res.Remaining = max(0, l.Limit-int(math.Ceil(estimate)))
+	return res
+}
+
+// 전처리기/핸들러. 저장소 에러는 요청을 막지 않음 (fail-open)
+func (l *Limiter) Handler(c *Context) {
+	res, err := l.Take(l.Key(c), time.Now())
+	if err != nil {
+		c.App.Logger.Warn("rate limit store", l.Name, err)
+		return
+	}
+
+	h := c.Res.Header()
+	h.Set("RateLimit-Limit", strconv.Itoa(l.Limit))
+	h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+
+	if !res.Allowed {
+		retry := ceilSeconds(res.RetryAfter)
+		h.Set("Retry-After", strconv.Itoa(retry))
+		NewAppError(CodeTooManyRequests, nil, map[string]any{
+			"Limiter":    l.Name,
+			"RetryAfter": retry,
+		}).Panic()
+	}
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// 메모리 저장소 : 샤드별 락 + 만료
+// /////////////////////////////////////////////////////////////////////////////
+
+const limitShards = 64
+
+type limitEntry struct {
+	state   LimitState
+	expires time.Time
+}
+
+type limitShard struct {
+	mu        sync.Mutex
+	entries   map[string]*limitEntry
+	lastSweep time.Time
+}
+
+type MemoryLimitStore struct {
+	shards [limitShards]limitShard
+}
+
+func NewMemoryLimitStore() *MemoryLimitStore {
+	s := &MemoryLimitStore{}
+	for i := range s.shards {
+		s.shards[i].entries = map[string]*limitEntry{}
+	}
+	return s
+}
+
+func (m *MemoryLimitStore) Update(key string, ttl time.Duration, fn func(s *LimitState)) error {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	sh := &m.shards[h.Sum32()%limitShards]
+	now := time.Now()
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	// 만료 항목은 샤드 단위로 가끔 정리
+	if now.Sub(sh.lastSweep) > ttl {
+		for k, e := range sh.entries {
+			if now.After(e.expires) {
+				delete(sh.entries, k)
+			}
+		}
+		sh.lastSweep = now
+	}
+
+	e, ok := sh.entries[key]
+	if !ok || now.After(e.expires) {
+		e = &limitEntry{}
+		sh.entries[key] = e
+	}
+	fn(&e.state)
+	e.expires = now.Add(ttl)
+	return nil
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// SQL 저장소 (MySQL)
+// /////////////////////////////////////////////////////////////////////////////
+
+/*
+App.Conns 의 커넥션을 쓰는 저장소. 여러 인스턴스가 한도를 공유할 때 사용
+테이블 예시:
+
+	CREATE TABLE rate_limit (
+	    k       VARCHAR(255) NOT NULL PRIMARY KEY,
+	    a       DOUBLE       NOT NULL,
+	    b       DOUBLE       NOT NULL,
+	    t       BIGINT       NOT NULL,
+	    expires BIGINT       NOT NULL, -- unix ms
+	    KEY (expires)
+	);
+
+l.Store = x.NewSQLLimitStore(a.GetConn("db1"), "rate_limit")
+*/
+type SQLLimitStore struct {
+	DB    *sql.DB
+	Table string
+}
+
+func NewSQLLimitStore(db *sql.DB, table string) *SQLLimitStore {
+	return &SQLLimitStore{DB: db, Table: table}
+}
+
+func (s *SQLLimitStore) Update(key string, ttl time.Duration, fn func(st *LimitState)) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var st LimitState
+	var expires int64
+	row := tx.QueryRow("SELECT a, b, t, expires FROM "+s.Table+" WHERE k = ? FOR UPDATE", key)
+	switch err := row.Scan(&st.A, &st.B, &st.T, &expires); {
+	case err == sql.ErrNoRows:
+	case err != nil:
+		return err
+	case time.Now().UnixMilli() > expires:
+		st = LimitState{}
+	}
+
+	fn(&st)
+
+	_, err = tx.Exec(
+		"INSERT INTO "+s.Table+" (k, a, b, t, expires) VALUES (?, ?, ?, ?, ?) "+
+			"ON DUPLICATE KEY UPDATE a = VALUES(a), b = VALUES(b), t = VALUES(t), expires = VALUES(expires)",
+		key, st.A, st.B, st.T, time.Now().Add(ttl).UnixMilli(),
+	)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// 만료된 행 삭제. Initialize 에서 주기 작업으로 등록해서 사용
+func (s *SQLLimitStore) Purge() error {
+	_, err := s.DB.Exec("DELETE FROM "+s.Table+" WHERE expires < ?", time.Now().UnixMilli())
+	return err
+}
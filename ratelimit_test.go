@@ -0,0 +1,99 @@
+package x
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTokenBucket(t *testing.T) {
+	l := NewLimiter("tb", TokenBucket, 3, 3*time.Second)
+	now := time.Unix(1000, 0)
+
+	for i := range 3 {
+		if res, _ := l.Take("k", now); !res.Allowed || res.Remaining != 2-i {
+			t.Fatalf("take %d = %+v", i, res)
+		}
+	}
+	res, _ := l.Take("k", now)
+	if res.Allowed || ceilSeconds(res.RetryAfter) != 1 || ceilSeconds(res.Reset) != 3 {
+		t.Fatalf("over limit = %+v", res)
+	}
+	// 1초에 토큰 하나씩 충전
+	if res, _ := l.Take("k", now.Add(time.Second)); !res.Allowed || res.Remaining != 0 {
+		t.Fatalf("after refill = %+v", res)
+	}
+	if res, _ := l.Take("other", now); !res.Allowed {
+		t.Fatal("keys share a bucket")
+	}
+}
+
+func TestSlidingWindow(t *testing.T) {
+	l := NewLimiter("sw", SlidingWindow, 2, 10*time.Second)
+	start := time.Unix(1000, 0) // 윈도우 경계
+
+	tests := []struct {
+		name    string
+		at      time.Duration
+		allowed bool
+	}{
+		{"first", 0, true},
+		{"second", time.Second, true},
+		{"over limit", 2 * time.Second, false},
+		{"next window weighs previous", 10 * time.Second, false},
+		{"half of previous counted", 15 * time.Second, true},
+		{"full again", 15 * time.Second, false},
+		{"window skipped", 30 * time.Second, true},
+	}
+	for _, tt := range tests {
+		res, err := l.Take("k", start.Add(tt.at))
+		if err != nil || res.Allowed != tt.allowed {
+			t.Fatalf("%s: %+v, %v", tt.name, res, err)
+		}
+		if !res.Allowed && res.RetryAfter != res.Reset {
+			t.Fatalf("%s: RetryAfter %v, Reset %v", tt.name, res.RetryAfter, res.Reset)
+		}
+	}
+}
+
+func TestMemoryLimitStoreExpiry(t *testing.T) {
+	s := NewMemoryLimitStore()
+	ttl := 20 * time.Millisecond
+	s.Update("k", ttl, func(st *LimitState) { st.A = 5 })
+
+	var got LimitState
+	s.Update("k", ttl, func(st *LimitState) { got = *st })
+	if got.A != 5 {
+		t.Fatalf("state lost before ttl: %+v", got)
+	}
+
+	time.Sleep(2 * ttl)
+	s.Update("k", ttl, func(st *LimitState) { got = *st })
+	if got != (LimitState{}) {
+		t.Fatalf("expired state kept: %+v", got)
+	}
+}
+
+func TestKeyByRoute(t *testing.T) {
+	for _, key := range []func(c *Context) string{KeyByRoute, KeyByRouteIP} {
+		a := NewApp()
+		l := NewLimiter("route", TokenBucket, 1, time.Hour)
+		l.Key = key
+		a.Router.AddPreprocessors(l.Handler)
+		a.Router.AddRoute(a, "POST", "/login", ReplyJSON)
+
+		// 전역 전처리기에서도 같은 라우트로 가는 경로는 하나의 버킷
+		for _, paths := range [][]string{
+			{"/login", "//login", "/login/", "///login//"},
+			{"/missing", "//missing/"},
+		} {
+			for i, path := range paths {
+				body := serve(a, httptest.NewRequest("POST", path, nil))
+				if limited := strings.Contains(body, CodeTooManyRequests); limited != (i > 0) {
+					t.Fatalf("%s: limited = %v, body %q", path, limited, body)
+				}
+			}
+		}
+	}
+}
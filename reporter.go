@@ -0,0 +1,414 @@
+package x
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/simjinhyun/x/util"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// ErrorReporter
+// /////////////////////////////////////////////////////////////////////////////
+
+// 패닉 발생 당시의 요청 스냅샷
+type RequestSnapshot struct {
+	Method   string
+	URL      string
+	Header   map[string]string
+	RemoteIP string
+	Body     string
+}
+
+// 리포터에 전달되는 에러 정보
+type ErrorReport struct {
+	ReqID    string
+	Time     time.Time
+	AppError *AppError
+	Stack    string
+	Request  RequestSnapshot
+}
+
+// JSON 직렬화. AppError.Err 는 인터페이스라 {} 로 나가므로 문자열로 풀어서 내보냄
+func (r *ErrorReport) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		ReqID   string
+		Time    time.Time
+		Code    string
+		Src     string
+		Error   string
+		Data    map[string]any
+		Stack   string
+		Request RequestSnapshot
+	}{
+		ReqID:   r.ReqID,
+		Time:    r.Time,
+		Code:    r.AppError.Code,
+		Src:     r.AppError.Src,
+		Error:   r.AppError.Error(),
+		Data:    r.AppError.Data,
+		Stack:   r.Stack,
+		Request: r.Request,
+	})
+}
+
+// 에러 리포터 인터페이스. Recover 에서 동기적으로 호출되므로 오래 걸리는 작업은 내부에서 비동기로 처리할것
+type ErrorReporter interface {
+	Report(r *ErrorReport)
+}
+
+// 스냅샷에 남기지 않는 헤더
+var snapshotHiddenHeaders = map[string]bool{
+	"Authorization": true,
+	"Cookie":        true,
+	"Set-Cookie":    true,
+}
+
+func newRequestSnapshot(c *Context) RequestSnapshot {
+	header := make(map[string]string, len(c.Req.Header))
+	for k, v := range c.Req.Header {
+		if snapshotHiddenHeaders[k] {
+			header[k] = "[FILTERED]"
+			continue
+		}
+		header[k] = strings.Join(v, ", ")
+	}
+	return RequestSnapshot{
+		Method:   c.Req.Method,
+		URL:      c.Req.URL.String(),
+		Header:   header,
+		RemoteIP: c.RemoteIP,
+		Body:     util.Truncate(c.ReqBody.String(), 4096),
+	}
+}
+
+// 리포터가 등록되어 있으면 에러 보고
+func (c *Context) reportError(appErr *AppError, stack []byte) {
+	if c.App.Reporter == nil {
+		return
+	}
+	c.App.Reporter.Report(&ErrorReport{
+		ReqID:    c.ReqID,
+		Time:     time.Now(),
+		AppError: appErr,
+		Stack:    string(stack),
+		Request:  newRequestSnapshot(c),
+	})
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// ErrorCollector : 핑거프린트 그룹핑 + 레이트리밋 + 최근 에러 링버퍼
+// /////////////////////////////////////////////////////////////////////////////
+
+// 동일 핑거프린트 에러 묶음
+type ErrorGroup struct {
+	Fingerprint string
+	Code        string
+	Message     string
+	Count       int
+	Dropped     int
+	FirstSeen   time.Time
+	LastSeen    time.Time
+
+	windowStart time.Time
+	windowCount int
+}
+
+type ErrorCollector struct {
+	Next      ErrorReporter // 레이트리밋을 통과한 리포트를 넘겨받을 리포터 (nil 가능)
+	Limit     int           // Window 동안 그룹당 Next 로 넘길 최대 건수
+	Window    time.Duration
+	MaxGroups int // 그룹 최대 보관 수, 넘으면 가장 오래된 그룹 제거
+
+	mu     sync.Mutex
+	groups map[string]*ErrorGroup
+	ring   []*ErrorReport
+	head   int
+	filled bool
+}
+
+// 에러 컬렉터 생성자. size 는 최근 에러 링버퍼 크기
+func NewErrorCollector(size int, next ErrorReporter) *ErrorCollector {
+	if size <= 0 {
+		size = 100
+	}
+	return &ErrorCollector{
+		Next:      next,
+		Limit:     10,
+		Window:    time.Minute,
+		MaxGroups: 1000,
+		groups:    map[string]*ErrorGroup{},
+		ring:      make([]*ErrorReport, size),
+	}
+}
+
+// 고루틴 번호, 인자값, PC 오프셋을 제거한 스택으로 핑거프린트 계산
+func Fingerprint(r *ErrorReport) string {
+	h := sha256.New()
+	h.Write([]byte(r.AppError.Code))
+	h.Write([]byte{0})
+	h.Write([]byte(r.AppError.Src))
+
+	for _, line := range strings.Split(r.Stack, "\n") {
+		switch {
+		case line == "", strings.HasPrefix(line, "goroutine "), strings.HasPrefix(line, "created by "):
+			continue
+		case strings.HasPrefix(line, "\t"):
+			// 파일:라인 +0x오프셋
+			if i := strings.LastIndex(line, " +0x"); i >= 0 {
+				line = line[:i]
+			}
+		default:
+			// 함수명(인자...)
+			if i := strings.LastIndex(line, "("); i >= 0 {
+				line = line[:i]
+			}
+		}
+		h.Write([]byte{0})
+		h.Write([]byte(line))
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+func (ec *ErrorCollector) Report(r *ErrorReport) {
+	fp := Fingerprint(r)
+
+	ec.mu.Lock()
+	ec.ring[ec.head] = r
+	ec.head = (ec.head + 1) % len(ec.ring)
+	if ec.head == 0 {
+		ec.filled = true
+	}
+
+	g, ok := ec.groups[fp]
+	if !ok {
+		ec.evictOldestGroup()
+		g = &ErrorGroup{
+			Fingerprint: fp,
+			Code:        r.AppError.Code,
+			Message:     fmt.Sprint(r.AppError.Err),
+			FirstSeen:   r.Time,
+			windowStart: r.Time,
+		}
+		ec.groups[fp] = g
+	}
+	g.Count++
+	g.LastSeen = r.Time
+
+	if r.Time.Sub(g.windowStart) >= ec.Window {
+		g.windowStart = r.Time
+		g.windowCount = 0
+	}
+	forward := g.windowCount < ec.Limit
+	if forward {
+		g.windowCount++
+	} else {
+		g.Dropped++
+	}
+	ec.mu.Unlock()
+
+	if forward && ec.Next != nil {
+		ec.Next.Report(r)
+	}
+}
+
+// 락을 잡은 상태에서 호출
+func (ec *ErrorCollector) evictOldestGroup() {
+	if ec.MaxGroups <= 0 || len(ec.groups) < ec.MaxGroups {
+		return
+	}
+	var oldest *ErrorGroup
+	for _, g := range ec.groups {
+		if oldest == nil || g.LastSeen.Before(oldest.LastSeen) {
+			oldest = g
+		}
+	}
+	delete(ec.groups, oldest.Fingerprint)
+}
+
+// 최근 에러 목록 (최신순)
+func (ec *ErrorCollector) Recent() []*ErrorReport {
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+
+	n := ec.head
+	if ec.filled {
+		n = len(ec.ring)
+	}
+	out := make([]*ErrorReport, 0, n)
+	for i := 1; i <= n; i++ {
+		out = append(out, ec.ring[(ec.head-i+len(ec.ring))%len(ec.ring)])
+	}
+	return out
+}
+
+// 에러 그룹 목록 (최근 발생순)
+func (ec *ErrorCollector) Groups() []ErrorGroup {
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+
+	out := make([]ErrorGroup, 0, len(ec.groups))
+	for _, g := range ec.groups {
+		out = append(out, *g)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].LastSeen.After(out[j].LastSeen)
+	})
+	return out
+}
+
+/*
+관리자용 엔드포인트 핸들러. 접근 제어는 앞단 핸들러에서 할것
+a.Router.AddRoute(a, "GET", "/admin/errors", x.ReplyJSON, AdminOnly, collector.Handler)
+*/
+func (ec *ErrorCollector) Handler(c *Context) {
+	c.Response.Data = map[string]any{
+		"Groups": ec.Groups(),
+		"Recent": ec.Recent(),
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// SentryExporter : Sentry envelope 포맷으로 전송
+// /////////////////////////////////////////////////////////////////////////////
+
+type SentryExporter struct {
+	Endpoint    string // https://host/api/{project}/envelope/
+	PublicKey   string
+	DSN         string
+	Environment string
+	Release     string
+	Client      *http.Client
+	OnError     func(error)
+}
+
+/*
+DSN 형식: scheme://publicKey@host[:port]/projectID
+로컬 스텁 서버 주소를 DSN 으로 주면 그대로 테스트 가능
+*/
+func NewSentryExporter(dsn string) (*SentryExporter, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, err
+	}
+	if u.User == nil || u.User.Username() == "" {
+		return nil, fmt.Errorf("sentry dsn: public key missing")
+	}
+	project := strings.Trim(u.Path, "/")
+	if project == "" {
+		return nil, fmt.Errorf("sentry dsn: project id missing")
+	}
+	prefix := ""
+	if i := strings.LastIndex(project, "/"); i >= 0 {
+		prefix, project = "/"+project[:i], project[i+1:]
+	}
+	return &SentryExporter{
+		Endpoint:  fmt.Sprintf("%s://%s%s/api/%s/envelope/", u.Scheme, u.Host, prefix, project),
+		PublicKey: u.User.Username(),
+		DSN:       dsn,
+		Client:    &http.Client{Timeout: 5 * time.Second},
+		OnError:   func(error) {},
+	}, nil
+}
+
+// 요청 경로를 막지 않도록 비동기로 전송
+func (s *SentryExporter) Report(r *ErrorReport) {
+	go func() {
+		if err := s.Send(r); err != nil {
+			s.OnError(err)
+		}
+	}()
+}
+
+// 동기 전송
+func (s *SentryExporter) Send(r *ErrorReport) error {
+	body, err := s.Envelope(r)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", s.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-sentry-envelope")
+	req.Header.Set("X-Sentry-Auth", fmt.Sprintf(
+		"Sentry sentry_version=7, sentry_client=x/1.0, sentry_key=%s", s.PublicKey,
+	))
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("sentry: HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// envelope 직렬화 (헤더 / 아이템 헤더 / 이벤트 페이로드, 줄바꿈 구분)
+func (s *SentryExporter) Envelope(r *ErrorReport) ([]byte, error) {
+	eventID := strings.ReplaceAll(util.UUIDFromCryptoPackage(), "-", "")
+
+	event := map[string]any{
+		"event_id":    eventID,
+		"timestamp":   r.Time.UTC().Format(time.RFC3339Nano),
+		"platform":    "go",
+		"level":       "error",
+		"environment": s.Environment,
+		"release":     s.Release,
+		"fingerprint": []string{Fingerprint(r)},
+		"tags":        map[string]string{"req_id": r.ReqID, "code": r.AppError.Code},
+		"exception": map[string]any{
+			"values": []map[string]any{{
+				"type":  r.AppError.Code,
+				"value": fmt.Sprint(r.AppError.Err),
+			}},
+		},
+		"request": map[string]any{
+			"method":  r.Request.Method,
+			"url":     r.Request.URL,
+			"headers": r.Request.Header,
+			"data":    r.Request.Body,
+			"env":     map[string]string{"REMOTE_ADDR": r.Request.RemoteIP},
+		},
+		"extra": map[string]any{
+			"src":   r.AppError.Src,
+			"data":  r.AppError.Data,
+			"stack": r.Stack,
+		},
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+
+	header, _ := json.Marshal(map[string]string{
+		"event_id": eventID,
+		"sent_at":  time.Now().UTC().Format(time.RFC3339Nano),
+		"dsn":      s.DSN,
+	})
+	item, _ := json.Marshal(map[string]any{
+		"type":   "event",
+		"length": len(payload),
+	})
+
+	var buf bytes.Buffer
+	buf.Write(header)
+	buf.WriteByte('\n')
+	buf.Write(item)
+	buf.WriteByte('\n')
+	buf.Write(payload)
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,108 @@
+package x
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestErrorCollectorHandler(t *testing.T) {
+	a := NewApp()
+	ec := NewErrorCollector(10, nil)
+	a.Reporter = ec
+	a.Router.AddRoute(a, "GET", "/fail", ReplyJSON, func(c *Context) { panic(errors.New("boom")) })
+	a.Router.AddRoute(a, "GET", "/admin/errors", ReplyJSON, ec.Handler)
+
+	a.Server.Handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/fail", nil))
+	w := httptest.NewRecorder()
+	a.Server.Handler.ServeHTTP(w, httptest.NewRequest("GET", "/admin/errors", nil))
+
+	var res struct {
+		Data struct {
+			Groups []ErrorGroup
+			Recent []struct{ Code, Error string }
+		}
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Data.Recent) != 1 || res.Data.Recent[0].Error != "RuntimeError: boom" {
+		t.Fatalf("recent = %+v", res.Data.Recent)
+	}
+	if len(res.Data.Groups) != 1 || res.Data.Groups[0].Count != 1 {
+		t.Fatalf("groups = %+v", res.Data.Groups)
+	}
+}
+
+func TestSentryExporter(t *testing.T) {
+	var (
+		path, auth, ctype string
+		body              []byte
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, auth, ctype = r.URL.Path, r.Header.Get("X-Sentry-Auth"), r.Header.Get("Content-Type")
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer srv.Close()
+
+	dsn := strings.Replace(srv.URL, "://", "://pubkey@", 1) + "/42"
+	s, err := NewSentryExporter(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Environment = "test"
+	err = s.Send(&ErrorReport{
+		ReqID:    "req1",
+		Time:     time.Now(),
+		AppError: NewAppError("RuntimeError", errors.New("boom"), nil),
+		Request:  RequestSnapshot{Method: "GET", URL: "/fail"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if path != "/api/42/envelope/" || ctype != "application/x-sentry-envelope" || !strings.Contains(auth, "sentry_key=pubkey") {
+		t.Fatalf("request: path %q, content-type %q, auth %q", path, ctype, auth)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(string(body), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("envelope has %d lines: %q", len(lines), body)
+	}
+	var header struct {
+		EventID string `json:"event_id"`
+		DSN     string
+	}
+	var item struct {
+		Type   string
+		Length int
+	}
+	var event struct {
+		EventID     string `json:"event_id"`
+		Environment string
+		Tags        map[string]string
+		Exception   struct {
+			Values []struct{ Type, Value string }
+		}
+	}
+	for i, v := range []any{&header, &item, &event} {
+		if err := json.Unmarshal([]byte(lines[i]), v); err != nil {
+			t.Fatalf("line %d: %v", i, err)
+		}
+	}
+	if header.DSN != dsn || header.EventID == "" || header.EventID != event.EventID {
+		t.Errorf("envelope header = %+v, event id %q", header, event.EventID)
+	}
+	if item.Type != "event" || item.Length != len(lines[2]) {
+		t.Errorf("item header = %+v, payload length %d", item, len(lines[2]))
+	}
+	if event.Environment != "test" || event.Tags["req_id"] != "req1" ||
+		len(event.Exception.Values) != 1 || event.Exception.Values[0].Value != "boom" {
+		t.Errorf("event = %+v", event)
+	}
+}
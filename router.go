@@ -1,6 +1,7 @@
 package x
 
 import (
+	"database/sql"
 	"io/fs"
 	"net/http"
 	"os"
@@ -12,9 +13,17 @@ import (
 
 type Router struct {
 	WebRoot           string
+	FS                fs.FS  // 지정하면 WebRoot 대신 사용 (embed.FS, zip, OverlayFS 등)
+	DirStatus         int    // 디렉토리 요청 시 응답 코드 (403 또는 404)
+	IndexFile         string // 디렉토리 요청 시 대신 응답할 파일 ("" 이면 사용 안함)
+	HideDotfiles      bool   // 점으로 시작하는 파일/디렉토리 숨김
+	CacheRules        []CacheRule
+	SPAFallback       string   // 없는 경로를 대신할 파일 (예: "index.html", "" 이면 사용 안함)
+	SPAExclude        []string // SPA 폴백에서 제외할 경로 접두사
 	trees             map[string]*node
 	preprocessors     []HandlerFunc
 	preprocessorNames []string
+	etags             etagCache
 }
 
 type node struct {
@@ -55,49 +64,57 @@ func NewRouter() *Router {
 	WebRoot := filepath.Join(dir, "www")
 
 	return &Router{
-		WebRoot: WebRoot,
-		trees:   make(map[string]*node),
+		WebRoot:      WebRoot,
+		DirStatus:    http.StatusNotFound,
+		IndexFile:    "index.html",
+		HideDotfiles: true,
+		CacheRules:   DefaultCacheRules,
+		SPAExclude:   []string{"/api/"},
+		trees:        make(map[string]*node),
 	}
 }
 
-func (r *Router) CreateIndexFiles() {
-	if err := os.MkdirAll(r.WebRoot, 0755); err != nil {
-		panic(err)
-	}
-	filepath.WalkDir(r.WebRoot, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			panic(err)
-		}
+type HandlerFunc func(*Context)
 
-		if d.IsDir() {
-			index := filepath.Join(path, "index.html")
-			_, statErr := os.Stat(index)
-
-			if os.IsNotExist(statErr) {
-				writeErr := os.WriteFile(index, []byte{}, 0644)
-				if writeErr != nil {
-					panic(writeErr)
-				}
-			} else if statErr != nil {
-				panic(statErr)
-			}
+// 에러를 반환하는 핸들러
+type ErrorHandlerFunc func(*Context) error
+
+/*
+에러 반환형 핸들러를 HandlerFunc 로 변환. nil 이 아닌 에러는 패닉으로 던져 Recover 에서 AppError 로 변환됨
+a.Router.AddRoute(a, "GET", "/user", x.ReplyJSON, x.E(GetUser))
+*/
+func E(h ErrorHandlerFunc) HandlerFunc {
+	name := runtime.FuncForPC(reflect.ValueOf(h).Pointer()).Name()
+	return func(c *Context) {
+		// Executed 에 래퍼 대신 원래 핸들러 이름이 남도록
+		if n := len(c.Executed); n > 0 {
+			c.Executed[n-1] = name
 		}
-		return nil
-	})
+		if err := h(c); err != nil {
+			// 변환은 Recover 에서 (RuntimeError 는 스택 기록과 보고 대상)
+			panic(err)
+		}
+	}
 }
 
-type HandlerFunc func(*Context)
-
 type Route struct {
-	Path         string
-	Method       string
-	Reply        HandlerFunc
-	Handlers     []HandlerFunc
-	HandlerNames []string
-	App          *App
+	Path               string
+	Method             string
+	Reply              HandlerFunc
+	Handlers           []HandlerFunc
+	HandlerNames       []string
+	App                *App
+	MaxBodySize        int64         // 0 이면 App.MaxBodySize, 음수면 제한 없음
+	Authenticators     []HandlerFunc // 권한 검사 전에 실행할 인증 핸들러 (Authenticate)
+	AuthenticatorNames []string
+	Roles              []string       // 이 중 하나의 역할 필요 (RequireRole)
+	Permissions        []string       // 모든 권한 필요 (Require)
+	TxOptions          *sql.TxOptions // c.Tx 의 격리 수준, 읽기 전용 (nil 이면 드라이버 기본값)
 }
 
-func (r *Router) AddRoute(app *App, method, path string, reply HandlerFunc, handlers ...HandlerFunc) {
+// 등록된 라우트를 반환하므로 라우트별 설정 가능
+// a.Router.AddRoute(a, "POST", "/upload", x.ReplyJSON, Upload).MaxBodySize = 100 << 20
+func (r *Router) AddRoute(app *App, method, path string, reply HandlerFunc, handlers ...HandlerFunc) *Route {
 	// 루트 노드 준비
 	if r.trees[method] == nil {
 		r.trees[method] = &node{children: make(map[string]*node)}
@@ -126,6 +143,7 @@ func (r *Router) AddRoute(app *App, method, path string, reply HandlerFunc, hand
 		HandlerNames: names,
 		App:          app,
 	}
+	return cur.route
 }
 
 func (r *Router) findRoute(method, path string) *Route {
@@ -149,23 +167,38 @@ func (r *Router) findRoute(method, path string) *Route {
 }
 
 func (r *Router) ServeHTTP(c *Context) {
+	// 본문 크기 제한은 라우트별 설정을 따르므로 먼저 찾음 (c.Route 는 전처리기 이후에 설정)
+	route := r.findRoute(c.Req.Method, c.Req.URL.Path)
+	c.prepareBody(route)
+
 	// 글로벌 전처리기 실행
 	for i, pre := range r.preprocessors {
 		c.Executed = append(c.Executed, r.preprocessorNames[i])
 		pre(c)
 	}
 
-	route := r.findRoute(c.Req.Method, c.Req.URL.Path)
 	if route != nil {
+		c.Route = route
+		// 인증 핸들러 → 권한 검사 → 라우트 핸들러 순서. 핸들러가 없어도 검사함
+		for i, h := range route.Authenticators {
+			c.Executed = append(c.Executed, route.AuthenticatorNames[i])
+			h(c)
+		}
+		if route.restricted() && !c.authorized {
+			c.Executed = append(c.Executed, "x.Policy.Handler")
+			c.policy().Handler(c)
+		}
 		for i, h := range route.Handlers {
 			c.Executed = append(c.Executed, route.HandlerNames[i])
-			c.Route = route
 			h(c)
 		}
 		return
 	}
 
 	// 등록된 라우트가 없으면 정적 파일 제공
-	path := filepath.Join(r.WebRoot, c.Req.URL.Path)
-	http.ServeFile(c.Res, c.Req, path)
+	fsys := r.FS
+	if fsys == nil {
+		fsys = os.DirFS(r.WebRoot)
+	}
+	r.serveFS(c, fsys)
 }
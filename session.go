@@ -0,0 +1,385 @@
+package x
+
+import (
+	crand "crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/simjinhyun/x/util"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// 세션
+// /////////////////////////////////////////////////////////////////////////////
+
+// 저장소에 저장되는 세션 내용. Values 는 JSON 으로 직렬화되므로 숫자는 float64 로 돌아옴
+type SessionData struct {
+	ID       string
+	Values   map[string]any
+	Created  time.Time
+	LastSeen time.Time
+}
+
+/*
+세션 저장소. 쿠키 값과 세션 내용 사이의 변환을 담당
+  - 서버 저장소(메모리, SQL): 쿠키 값 = 세션 ID
+  - 쿠키 저장소: 쿠키 값 = 암호화된 세션 내용
+*/
+type SessionStore interface {
+	Load(cookie string) (*SessionData, error) // 없으면 nil, nil
+	Save(d *SessionData, ttl time.Duration) (cookie string, err error)
+	Delete(id string) error
+}
+
+type SessionManager struct {
+	Store           SessionStore
+	CookieName      string
+	IdleTimeout     time.Duration // 마지막 요청 이후 만료 (0 이면 없음)
+	AbsoluteTimeout time.Duration // 생성 이후 만료 (0 이면 없음)
+}
+
+/*
+세션 매니저 생성. App.Sessions 에 지정하면 c.Session() 사용 가능
+a.Sessions = x.NewSessionManager(x.NewMemorySessionStore())
+*/
+func NewSessionManager(store SessionStore) *SessionManager {
+	return &SessionManager{
+		Store:           store,
+		CookieName:      "sid",
+		IdleTimeout:     30 * time.Minute,
+		AbsoluteTimeout: 12 * time.Hour,
+	}
+}
+
+// 저장소 보관 기간
+func (m *SessionManager) ttl(d *SessionData) time.Duration {
+	ttl := m.IdleTimeout
+	if m.AbsoluteTimeout > 0 {
+		remain := time.Until(d.Created.Add(m.AbsoluteTimeout))
+		if ttl == 0 || remain < ttl {
+			ttl = remain
+		}
+	}
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+	return ttl
+}
+
+func (m *SessionManager) expired(d *SessionData, now time.Time) bool {
+	if m.IdleTimeout > 0 && now.Sub(d.LastSeen) > m.IdleTimeout {
+		return true
+	}
+	return m.AbsoluteTimeout > 0 && now.Sub(d.Created) > m.AbsoluteTimeout
+}
+
+func newSessionID() string {
+	b := make([]byte, 32)
+	if _, err := crand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+type Session struct {
+	SessionData
+	mgr       *SessionManager
+	oldID     string
+	destroyed bool
+}
+
+/*
+요청의 세션. 처음 호출할 때 쿠키에서 읽고, 응답 직전에 저장하고 쿠키를 갱신함
+만료되었거나 없으면 새 세션
+*/
+func (c *Context) Session() *Session {
+	if c.session != nil {
+		return c.session
+	}
+	m := c.App.Sessions
+	if m == nil {
+		panic(errors.New("App.Sessions is not configured"))
+	}
+
+	now := time.Now()
+	var data *SessionData
+	if ck, err := c.Req.Cookie(m.CookieName); err == nil && ck.Value != "" {
+		d, err := m.Store.Load(ck.Value)
+		if err != nil {
+			c.App.Logger.Warn("session load", err)
+		} else if d != nil && !m.expired(d, now) {
+			data = d
+		} else if d != nil {
+			m.Store.Delete(d.ID)
+		}
+	}
+	if data == nil {
+		data = &SessionData{ID: newSessionID(), Values: map[string]any{}, Created: now}
+	}
+	if data.Values == nil {
+		data.Values = map[string]any{}
+	}
+	data.LastSeen = now
+
+	c.session = &Session{SessionData: *data, mgr: m}
+	c.BeforeReply(c.saveSession)
+	return c.session
+}
+
+func (c *Context) saveSession() {
+	s := c.session
+	m := s.mgr
+
+	if s.oldID != "" {
+		if err := m.Store.Delete(s.oldID); err != nil {
+			c.App.Logger.Warn("session delete", err)
+		}
+	}
+	if s.destroyed {
+		if err := m.Store.Delete(s.ID); err != nil {
+			c.App.Logger.Warn("session delete", err)
+		}
+		util.ClearCookie(c.Res, m.CookieName)
+		return
+	}
+
+	value, err := m.Store.Save(&s.SessionData, m.ttl(&s.SessionData))
+	if err != nil {
+		c.App.Logger.Error("session save", err)
+		return
+	}
+	http.SetCookie(c.Res, util.SessionCookie(m.CookieName, value))
+}
+
+func (s *Session) Get(key string) any        { return s.Values[key] }
+func (s *Session) Set(key string, value any) { s.Values[key] = value }
+func (s *Session) Delete(key string)         { delete(s.Values, key) }
+
+// 로그인 등 권한이 바뀔 때 호출해서 세션 고정 공격 방지
+func (s *Session) Regenerate() {
+	if s.oldID == "" {
+		s.oldID = s.ID
+	}
+	s.ID = newSessionID()
+	s.Created = time.Now()
+}
+
+// 로그아웃
+func (s *Session) Destroy() {
+	s.destroyed = true
+	s.Values = map[string]any{}
+}
+
+const flashKey = "_flash"
+
+// 다음 요청에서 한 번만 읽을 메시지
+func (s *Session) AddFlash(msg string) {
+	list, _ := s.Values[flashKey].([]any)
+	s.Values[flashKey] = append(list, msg)
+}
+
+// 플래시 메시지를 읽고 비움
+func (s *Session) Flashes() []string {
+	list, _ := s.Values[flashKey].([]any)
+	delete(s.Values, flashKey)
+	out := make([]string, 0, len(list))
+	for _, v := range list {
+		if m, ok := v.(string); ok {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// 쿠키 저장소 : AES-GCM 암호화, 키 교체 지원
+// /////////////////////////////////////////////////////////////////////////////
+
+/*
+세션 내용을 쿠키에 암호화해서 저장. 브라우저 쿠키 한도(약 4KB)를 넘지 않게 작게 유지할것
+Keys[0] 로 암호화하고, 복호화는 모든 키로 시도. 키 교체 시 새 키를 앞에 추가하고
+기존 세션이 만료된 뒤 이전 키 제거
+*/
+type CookieSessionStore struct {
+	Keys [][]byte // AES 키 (16/24/32 바이트)
+}
+
+func NewCookieSessionStore(keys ...[]byte) *CookieSessionStore {
+	return &CookieSessionStore{Keys: keys}
+}
+
+type cookiePayload struct {
+	SessionData
+	Expires time.Time
+}
+
+func (s *CookieSessionStore) Load(cookie string) (*SessionData, error) {
+	for _, key := range s.Keys {
+		plain, err := util.AESGCMDecrypt(cookie, key)
+		if err != nil {
+			continue
+		}
+		var p cookiePayload
+		if err := json.Unmarshal([]byte(plain), &p); err != nil {
+			return nil, err
+		}
+		if time.Now().After(p.Expires) {
+			return nil, nil
+		}
+		return &p.SessionData, nil
+	}
+	// 변조되었거나 폐기된 키로 암호화된 쿠키
+	return nil, nil
+}
+
+func (s *CookieSessionStore) Save(d *SessionData, ttl time.Duration) (string, error) {
+	if len(s.Keys) == 0 {
+		return "", errors.New("cookie session store: no key")
+	}
+	b, err := json.Marshal(cookiePayload{SessionData: *d, Expires: time.Now().Add(ttl)})
+	if err != nil {
+		return "", err
+	}
+	return util.AESGCMEncrypt(string(b), s.Keys[0])
+}
+
+// 쿠키 저장소는 서버에 남는 것이 없음
+func (s *CookieSessionStore) Delete(id string) error { return nil }
+
+// /////////////////////////////////////////////////////////////////////////////
+// 메모리 저장소
+// /////////////////////////////////////////////////////////////////////////////
+
+type memorySession struct {
+	data    []byte
+	expires time.Time
+}
+
+type MemorySessionStore struct {
+	mu        sync.Mutex
+	items     map[string]memorySession
+	lastSweep time.Time
+}
+
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{items: map[string]memorySession{}}
+}
+
+func (m *MemorySessionStore) Load(id string) (*SessionData, error) {
+	m.mu.Lock()
+	item, ok := m.items[id]
+	m.mu.Unlock()
+	if !ok || time.Now().After(item.expires) {
+		return nil, nil
+	}
+	var d SessionData
+	if err := json.Unmarshal(item.data, &d); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (m *MemorySessionStore) Save(d *SessionData, ttl time.Duration) (string, error) {
+	b, err := json.Marshal(d)
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if now.Sub(m.lastSweep) > time.Minute {
+		for k, v := range m.items {
+			if now.After(v.expires) {
+				delete(m.items, k)
+			}
+		}
+		m.lastSweep = now
+	}
+	m.items[d.ID] = memorySession{data: b, expires: now.Add(ttl)}
+	return d.ID, nil
+}
+
+func (m *MemorySessionStore) Delete(id string) error {
+	m.mu.Lock()
+	delete(m.items, id)
+	m.mu.Unlock()
+	return nil
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// SQL 저장소 (MySQL)
+// /////////////////////////////////////////////////////////////////////////////
+
+/*
+App.Conns 의 커넥션을 쓰는 세션 저장소
+테이블 예시:
+
+	CREATE TABLE sessions (
+	    id      CHAR(64)   NOT NULL PRIMARY KEY,
+	    data    MEDIUMTEXT NOT NULL,
+	    expires BIGINT     NOT NULL, -- unix ms
+	    KEY (expires)
+	);
+
+a.Sessions = x.NewSessionManager(x.NewSQLSessionStore(a.GetConn("db1"), "sessions"))
+*/
+type SQLSessionStore struct {
+	DB    *sql.DB
+	Table string
+}
+
+func NewSQLSessionStore(db *sql.DB, table string) *SQLSessionStore {
+	return &SQLSessionStore{DB: db, Table: table}
+}
+
+func (s *SQLSessionStore) Load(id string) (*SessionData, error) {
+	var data string
+	err := s.DB.QueryRow(
+		"SELECT data FROM "+s.Table+" WHERE id = ? AND expires > ?",
+		id, time.Now().UnixMilli(),
+	).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var d SessionData
+	if err := json.Unmarshal([]byte(data), &d); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (s *SQLSessionStore) Save(d *SessionData, ttl time.Duration) (string, error) {
+	b, err := json.Marshal(d)
+	if err != nil {
+		return "", err
+	}
+	_, err = s.DB.Exec(
+		"INSERT INTO "+s.Table+" (id, data, expires) VALUES (?, ?, ?) "+
+			"ON DUPLICATE KEY UPDATE data = VALUES(data), expires = VALUES(expires)",
+		d.ID, string(b), time.Now().Add(ttl).UnixMilli(),
+	)
+	if err != nil {
+		return "", err
+	}
+	return d.ID, nil
+}
+
+func (s *SQLSessionStore) Delete(id string) error {
+	_, err := s.DB.Exec("DELETE FROM "+s.Table+" WHERE id = ?", id)
+	return err
+}
+
+// 만료된 세션 삭제. 주기 작업으로 등록해서 사용
+func (s *SQLSessionStore) Purge() error {
+	_, err := s.DB.Exec("DELETE FROM "+s.Table+" WHERE expires < ?", time.Now().UnixMilli())
+	return err
+}
@@ -0,0 +1,159 @@
+package x
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// 세션 쿠키를 붙여 요청하고 응답 Data 와 새 세션 쿠키를 돌려줌
+func sessionRequest(t *testing.T, a *App, method, target string, sid *http.Cookie) (any, *http.Cookie) {
+	t.Helper()
+	r := httptest.NewRequest(method, target, nil)
+	if sid != nil {
+		r.AddCookie(sid)
+	}
+	w := do(a, r)
+	var res struct {
+		Code string
+		Data any
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil || res.Code != "OK" {
+		t.Fatalf("%s %s: %q, %v", method, target, w.Body.String(), err)
+	}
+	for _, ck := range w.Result().Cookies() {
+		if ck.Name == a.Sessions.CookieName {
+			return res.Data, ck
+		}
+	}
+	return res.Data, nil
+}
+
+func TestSessionRegenerate(t *testing.T) {
+	store := NewMemorySessionStore()
+	a := newSessionApp(store)
+
+	_, sid := sessionRequest(t, a, "POST", "/set?v=cart", nil)
+	_, next := sessionRequest(t, a, "POST", "/login", sid)
+	if next == nil || next.Value == sid.Value {
+		t.Fatalf("session id not regenerated: %v", next)
+	}
+	if d, _ := store.Load(sid.Value); d != nil {
+		t.Fatal("old session id still in store")
+	}
+	// 값은 새 ID 로 옮겨감
+	if v, _ := sessionRequest(t, a, "GET", "/get", next); v != "cart" {
+		t.Fatalf("value after regenerate = %v", v)
+	}
+	if v, _ := sessionRequest(t, a, "GET", "/get", sid); v != nil {
+		t.Fatalf("old cookie still valid: %v", v)
+	}
+}
+
+func TestSessionDestroy(t *testing.T) {
+	store := NewMemorySessionStore()
+	a := newSessionApp(store)
+
+	_, sid := sessionRequest(t, a, "POST", "/set?v=user", nil)
+	_, cleared := sessionRequest(t, a, "POST", "/logout", sid)
+	if cleared == nil || cleared.Value != "" || cleared.MaxAge >= 0 {
+		t.Fatalf("cookie not cleared: %v", cleared)
+	}
+	if d, _ := store.Load(sid.Value); d != nil {
+		t.Fatal("destroyed session still in store")
+	}
+}
+
+func TestSessionFlash(t *testing.T) {
+	a := newSessionApp(NewMemorySessionStore())
+
+	_, sid := sessionRequest(t, a, "POST", "/flash", nil)
+	v, sid := sessionRequest(t, a, "GET", "/flashes", sid)
+	if list, ok := v.([]any); !ok || len(list) != 1 || list[0] != "saved" {
+		t.Fatalf("flashes = %v", v)
+	}
+	// 한 번 읽으면 사라짐
+	if v, _ := sessionRequest(t, a, "GET", "/flashes", sid); len(v.([]any)) != 0 {
+		t.Fatalf("flashes read twice: %v", v)
+	}
+}
+
+func TestSessionExpiry(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name     string
+		created  time.Time
+		lastSeen time.Time
+		expired  bool
+	}{
+		{"active", now.Add(-time.Hour), now.Add(-time.Minute), false},
+		{"idle", now.Add(-time.Hour), now.Add(-31 * time.Minute), true},
+		{"absolute", now.Add(-13 * time.Hour), now.Add(-time.Minute), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewMemorySessionStore()
+			a := newSessionApp(store)
+			d := &SessionData{ID: newSessionID(), Values: map[string]any{"v": "old"}, Created: tt.created, LastSeen: tt.lastSeen}
+			store.Save(d, time.Hour)
+
+			v, sid := sessionRequest(t, a, "GET", "/get", &http.Cookie{Name: "sid", Value: d.ID})
+			if tt.expired {
+				if v != nil || sid.Value == d.ID {
+					t.Fatalf("expired session reused: %v, %s", v, sid.Value)
+				}
+				if old, _ := store.Load(d.ID); old != nil {
+					t.Fatal("expired session not deleted")
+				}
+				return
+			}
+			if v != "old" || sid.Value != d.ID {
+				t.Fatalf("active session lost: %v, %s", v, sid.Value)
+			}
+		})
+	}
+}
+
+func TestCookieSessionStoreRotation(t *testing.T) {
+	oldKey := []byte("0123456789abcdef0123456789abcdef")
+	newKey := []byte("fedcba9876543210fedcba9876543210")
+	d := &SessionData{ID: "s1", Values: map[string]any{"v": "x"}, Created: time.Now(), LastSeen: time.Now()}
+
+	old := NewCookieSessionStore(oldKey)
+	cookie, err := old.Save(d, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 새 키를 앞에 추가해도 이전 키로 만든 쿠키는 읽힘
+	rotated := NewCookieSessionStore(newKey, oldKey)
+	got, err := rotated.Load(cookie)
+	if err != nil || got == nil || got.Values["v"] != "x" {
+		t.Fatalf("Load with old key = %v, %v", got, err)
+	}
+	// 저장은 새 키로
+	fresh, err := rotated.Save(got, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d, _ := old.Load(fresh); d != nil {
+		t.Fatal("saved with the old key")
+	}
+	// 이전 키를 빼면 이전 쿠키는 버려짐
+	if d, _ := NewCookieSessionStore(newKey).Load(cookie); d != nil {
+		t.Fatal("retired key still accepted")
+	}
+	if d, _ := NewCookieSessionStore(newKey).Load(fresh); d == nil {
+		t.Fatal("cookie with the new key rejected")
+	}
+
+	expired, _ := rotated.Save(d, -time.Second)
+	if d, _ := rotated.Load(expired); d != nil {
+		t.Fatal("expired cookie accepted")
+	}
+	if d, _ := rotated.Load(cookie + "x"); d != nil {
+		t.Fatal("tampered cookie accepted")
+	}
+}
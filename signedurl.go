@@ -0,0 +1,56 @@
+package x
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/simjinhyun/x/util"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// 서명된 URL 강제
+// /////////////////////////////////////////////////////////////////////////////
+
+// 만료된 링크/토큰
+const CodeExpired = "Expired"
+
+var ErrExpired = &AppError{Code: CodeExpired}
+
+func init() {
+	CodeStatus[CodeExpired] = http.StatusGone
+}
+
+/*
+util.TokenSigner.SignURL 로 서명된 URL 만 통과시키는 전처리기/핸들러
+prefixes 가 있으면 해당 경로로 시작하는 요청만 검사 (WebRoot 정적 파일에 전역 전처리기로 사용)
+서명이 틀리면 Forbidden, 만료되었으면 Expired
+
+	ts := util.NewTokenSigner(key)
+	a.Router.AddPreprocessors(x.RequireSignedURL(ts, "/downloads/"))
+	a.Router.AddRoute(a, "GET", "/export", x.ReplyJSON, x.RequireSignedURL(ts), Export)
+*/
+func RequireSignedURL(s *util.TokenSigner, prefixes ...string) HandlerFunc {
+	return func(c *Context) {
+		// serveFS 와 같은 방식으로 정리한 경로로 비교 ("/a/../downloads/x", "//downloads/x" 우회 방지)
+		if len(prefixes) > 0 && !hasAnyPrefix("/"+fsName(c.Req.URL.Path)+"/", prefixes) {
+			return
+		}
+		switch err := s.VerifyURL(c.Req.URL); {
+		case err == nil:
+		case errors.Is(err, util.ErrTokenExpired):
+			NewAppError(CodeExpired, err, nil).Panic()
+		default:
+			NewAppError(CodeForbidden, err, map[string]any{"Reason": "signature"}).Panic()
+		}
+	}
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
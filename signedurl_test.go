@@ -0,0 +1,44 @@
+package x
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/simjinhyun/x/util"
+)
+
+func TestRequireSignedURL(t *testing.T) {
+	ts := util.NewTokenSigner([]byte("test-key"))
+	a := newSignedURLApp(ts)
+
+	signed, _ := ts.SignURL("http://example.com/downloads/secret.txt", time.Hour)
+	expired, _ := ts.SignURL("http://example.com/downloads/secret.txt", -time.Second)
+
+	tests := []struct {
+		name   string
+		url    string
+		status int
+		body   string
+	}{
+		{"signed", signed, 200, "secret"},
+		{"tampered", signed + "0", 200, `"Code":"Forbidden"`},
+		{"unsigned", "/downloads/secret.txt", 200, `"Code":"Forbidden"`},
+		{"dot-dot", "/a/../downloads/secret.txt", 200, `"Code":"Forbidden"`},
+		{"double slash", "//downloads/secret.txt", 200, `"Code":"Forbidden"`},
+		{"directory", "/downloads", 200, `"Code":"Forbidden"`},
+		{"expired", expired, 200, `"Code":"Expired"`},
+		{"outside prefix", "/public.txt", 200, "public"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			a.Server.Handler.ServeHTTP(w, httptest.NewRequest("GET", tt.url, nil))
+			body := w.Body.String()
+			if w.Code != tt.status || !strings.Contains(body, tt.body) {
+				t.Fatalf("got %d %q, want %d containing %q", w.Code, body, tt.status, tt.body)
+			}
+		})
+	}
+}
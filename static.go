@@ -0,0 +1,313 @@
+package x
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// 정적 파일 (fs.FS)
+// /////////////////////////////////////////////////////////////////////////////
+
+// URL 경로를 fs.FS 이름으로 변환 ("/a/b" → "a/b", "/" → ".")
+func fsName(urlPath string) string {
+	name := strings.TrimPrefix(path.Clean("/"+urlPath), "/")
+	if name == "" {
+		return "."
+	}
+	return name
+}
+
+// 점으로 시작하는 경로 요소가 있는지 (.well-known 은 예외)
+func hasDotSegment(name string) bool {
+	for _, seg := range strings.Split(name, "/") {
+		if strings.HasPrefix(seg, ".") && seg != "." && seg != ".well-known" {
+			return true
+		}
+	}
+	return false
+}
+
+// 경로 패턴별 Cache-Control
+type CacheRule struct {
+	Match        *regexp.Regexp
+	CacheControl string
+}
+
+// 기본 캐시 정책: 해시가 붙은 에셋은 영구 캐시, HTML 은 매번 검증
+var DefaultCacheRules = []CacheRule{
+	{regexp.MustCompile(`[.-][0-9a-fA-F]{8,}\.[a-z0-9]+$`), "public, max-age=31536000, immutable"},
+	{regexp.MustCompile(`\.html?$`), "no-cache"},
+}
+
+// 미리 압축된 파일 확장자. 앞에 있는 것이 우선
+var precompressed = []struct{ encoding, ext string }{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+// Accept-Encoding 에서 해당 인코딩을 q > 0 으로 허용하는지
+func acceptsEncoding(r *http.Request, enc string) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		token, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if !strings.EqualFold(strings.TrimSpace(token), enc) && strings.TrimSpace(token) != "*" {
+			continue
+		}
+		k, v, _ := strings.Cut(strings.TrimSpace(params), "=")
+		if strings.TrimSpace(k) == "q" {
+			if q, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil && q == 0 {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// Vary 에 중복 없이 추가
+func addVary(h http.Header, field string) {
+	for _, v := range h.Values("Vary") {
+		for _, f := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(f), field) {
+				return
+			}
+		}
+	}
+	h.Add("Vary", field)
+}
+
+// ETag 캐시의 최대 항목 수. 넘치면 임의의 항목을 버림
+const maxETags = 4096
+
+/*
+라우터별 ETag 캐시. 키는 이름+수정시각+크기라서 파일이 바뀌면 자동으로 새로 계산됨
+수정시각이 없는 FS(embed.FS 등)끼리 섞이지 않도록 전역이 아닌 라우터에 둠
+*/
+type etagCache struct {
+	mu sync.Mutex
+	m  map[string]string
+}
+
+func (ec *etagCache) get(key string) (string, bool) {
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+	etag, ok := ec.m[key]
+	return etag, ok
+}
+
+func (ec *etagCache) put(key, etag string) {
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+	if ec.m == nil {
+		ec.m = map[string]string{}
+	}
+	if _, ok := ec.m[key]; !ok && len(ec.m) >= maxETags {
+		for k := range ec.m {
+			delete(ec.m, k)
+			break
+		}
+	}
+	ec.m[key] = etag
+}
+
+// 내용 기반 강한 ETag
+func (ec *etagCache) strongETag(name string, info fs.FileInfo, rs io.ReadSeeker) (string, error) {
+	key := fmt.Sprintf("%s|%d|%d", name, info.ModTime().UnixNano(), info.Size())
+	if etag, ok := ec.get(key); ok {
+		return etag, nil
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(h, rs); err != nil {
+		return "", err
+	}
+	if _, err := rs.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	etag := `"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+	ec.put(key, etag)
+	return etag, nil
+}
+
+/*
+fs.FS 에서 정적 파일 응답. 디스크에 아무것도 쓰지 않고 요청 시점에 정책 적용
+  - 디렉토리: IndexFile 이 있으면 그 파일, 없으면 DirStatus (목록은 절대 노출 안함)
+  - HideDotfiles: .git, .env 같은 점 파일/디렉토리는 404
+  - SPAFallback: 확장자 없는 미등록 경로는 지정 파일로 응답 (SPAExclude 접두사 제외)
+  - CacheRules 에 따른 Cache-Control, 내용 기반 ETag, .br/.gz 사전압축 파일 우선
+*/
+func (r *Router) serveFS(c *Context, fsys fs.FS) {
+	name := fsName(c.Req.URL.Path)
+
+	if r.HideDotfiles && hasDotSegment(name) {
+		http.NotFound(c.Res, c.Req)
+		return
+	}
+
+	info, err := fs.Stat(fsys, name)
+	if err != nil {
+		if !r.spaFallback(c, name) {
+			http.NotFound(c.Res, c.Req)
+			return
+		}
+		name = r.SPAFallback
+		if info, err = fs.Stat(fsys, name); err != nil || info.IsDir() {
+			http.NotFound(c.Res, c.Req)
+			return
+		}
+	}
+	if info.IsDir() {
+		if r.IndexFile == "" {
+			http.Error(c.Res, http.StatusText(r.DirStatus), r.DirStatus)
+			return
+		}
+		index := path.Join(name, r.IndexFile)
+		indexInfo, err := fs.Stat(fsys, index)
+		if err != nil || indexInfo.IsDir() {
+			http.Error(c.Res, http.StatusText(r.DirStatus), r.DirStatus)
+			return
+		}
+		// 상대경로 링크가 깨지지 않도록 디렉토리는 슬래시로 끝나게
+		if !strings.HasSuffix(c.Req.URL.Path, "/") {
+			http.Redirect(c.Res, c.Req, path.Base(c.Req.URL.Path)+"/", http.StatusMovedPermanently)
+			return
+		}
+		name, info = index, indexInfo
+	}
+
+	h := c.Res.Header()
+	for _, rule := range r.CacheRules {
+		if rule.Match.MatchString(name) {
+			h.Set("Cache-Control", rule.CacheControl)
+			break
+		}
+	}
+
+	// 사전압축 파일이 있으면 그것을 응답. Content-Type 은 원본 이름으로 결정됨
+	served, servedInfo := name, info
+	for _, p := range precompressed {
+		if !acceptsEncoding(c.Req, p.encoding) {
+			continue
+		}
+		if ci, err := fs.Stat(fsys, name+p.ext); err == nil && !ci.IsDir() {
+			served, servedInfo = name+p.ext, ci
+			h.Set("Content-Encoding", p.encoding)
+			break
+		}
+	}
+	addVary(h, "Accept-Encoding")
+
+	f, err := fsys.Open(served)
+	if err != nil {
+		http.NotFound(c.Res, c.Req)
+		return
+	}
+	defer f.Close()
+
+	// zip 등 Seek 을 지원하지 않는 파일은 메모리로 읽어서 응답
+	rs, ok := f.(io.ReadSeeker)
+	if !ok {
+		b, err := io.ReadAll(f)
+		if err != nil {
+			http.Error(c.Res, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		rs = bytes.NewReader(b)
+	}
+
+	etag, err := r.etags.strongETag(served, servedInfo, rs)
+	if err != nil {
+		http.Error(c.Res, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.Set("ETag", etag)
+	http.ServeContent(c.Res, c.Req, path.Base(name), servedInfo.ModTime(), rs)
+}
+
+// SPA 폴백 대상인지: 설정되어 있고, GET/HEAD 이고, 확장자가 없고, 제외 접두사가 아닐 것
+func (r *Router) spaFallback(c *Context, name string) bool {
+	if r.SPAFallback == "" || path.Ext(name) != "" {
+		return false
+	}
+	if c.Req.Method != http.MethodGet && c.Req.Method != http.MethodHead {
+		return false
+	}
+	for _, prefix := range r.SPAExclude {
+		if strings.HasPrefix(c.Req.URL.Path, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// OverlayFS : 여러 FS 를 겹쳐서 앞쪽 레이어 우선
+// /////////////////////////////////////////////////////////////////////////////
+
+/*
+앞쪽 레이어의 파일이 뒤쪽을 가림. 디렉토리 목록은 모든 레이어를 합쳐서 반환
+r.FS = x.NewOverlayFS(os.DirFS("override"), embeddedFS)
+zip 아카이브는 zip.OpenReader 결과를 그대로 레이어로 사용 가능
+*/
+type OverlayFS struct {
+	Layers []fs.FS
+}
+
+func NewOverlayFS(layers ...fs.FS) *OverlayFS {
+	return &OverlayFS{Layers: layers}
+}
+
+func (o *OverlayFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	for _, l := range o.Layers {
+		f, err := l.Open(name)
+		if err == nil {
+			return f, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+func (o *OverlayFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	seen := map[string]bool{}
+	var out []fs.DirEntry
+	found := false
+	for _, l := range o.Layers {
+		entries, err := fs.ReadDir(l, name)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		found = true
+		for _, e := range entries {
+			if !seen[e.Name()] {
+				seen[e.Name()] = true
+				out = append(out, e)
+			}
+		}
+	}
+	if !found {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name() < out[j].Name() })
+	return out, nil
+}
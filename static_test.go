@@ -0,0 +1,269 @@
+package x
+
+import (
+	"fmt"
+	"io/fs"
+	"mime"
+	"net/http/httptest"
+	"path"
+	"regexp"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestStaticETag(t *testing.T) {
+	// 수정시각이 없고 크기가 같은 파일이라도 라우터(FS)가 다르면 ETag 도 달라야 함
+	etagOf := func(data string) (*App, string) {
+		a := NewApp()
+		a.Router.FS = fstest.MapFS{"app.js": {Data: []byte(data)}}
+		return a, do(a, httptest.NewRequest("GET", "/app.js", nil)).Header().Get("ETag")
+	}
+	a, first := etagOf("one")
+	_, second := etagOf("two")
+	if first == "" || first == second {
+		t.Fatalf("ETags %q, %q", first, second)
+	}
+
+	r := httptest.NewRequest("GET", "/app.js", nil)
+	r.Header.Set("If-None-Match", first)
+	if w := do(a, r); w.Code != 304 {
+		t.Fatalf("If-None-Match: status %d", w.Code)
+	}
+	r = httptest.NewRequest("GET", "/app.js", nil)
+	r.Header.Set("If-None-Match", second)
+	if w := do(a, r); w.Code != 200 {
+		t.Fatalf("other router's ETag: status %d", w.Code)
+	}
+}
+
+func TestETagCacheLimit(t *testing.T) {
+	var ec etagCache
+	for i := range maxETags + 10 {
+		ec.put(fmt.Sprint(i), "etag")
+	}
+	if len(ec.m) != maxETags {
+		t.Fatalf("cache size %d", len(ec.m))
+	}
+	ec.put("last", "etag")
+	if _, ok := ec.get("last"); !ok {
+		t.Fatal("newest entry evicted")
+	}
+}
+
+func TestServeFSOverlay(t *testing.T) {
+	a := NewApp()
+	a.Router.FS = NewOverlayFS(
+		fstest.MapFS{"site.css": {Data: []byte("override")}},
+		fstest.MapFS{
+			"site.css":     {Data: []byte("base")},
+			"img/logo.svg": {Data: []byte("<svg/>")},
+		},
+	)
+
+	tests := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/site.css", 200, "override"},
+		{"/img/logo.svg", 200, "<svg/>"},
+		{"/img/../site.css", 200, "override"},
+		{"/missing.css", 404, ""},
+	}
+	for _, tt := range tests {
+		w := do(a, httptest.NewRequest("GET", tt.path, nil))
+		if w.Code != tt.status || !strings.Contains(w.Body.String(), tt.body) {
+			t.Errorf("%s: status %d, body %q", tt.path, w.Code, w.Body.String())
+		}
+	}
+
+	entries, err := fs.ReadDir(a.Router.FS, ".")
+	if err != nil || len(entries) != 2 || entries[0].Name() != "img" || entries[1].Name() != "site.css" {
+		t.Fatalf("ReadDir = %v, %v", entries, err)
+	}
+}
+
+func TestServeFSDirectoryPolicy(t *testing.T) {
+	fsys := fstest.MapFS{
+		"docs/index.html": {Data: []byte("docs index")},
+		"assets/app.js":   {Data: []byte("app")},
+	}
+
+	tests := []struct {
+		name      string
+		dirStatus int
+		index     string
+		path      string
+		status    int
+		location  string
+		body      string
+	}{
+		{"index served", 404, "index.html", "/docs/", 200, "", "docs index"},
+		{"redirect to slash", 404, "index.html", "/docs", 301, "/docs/", ""},
+		{"no index 404", 404, "index.html", "/assets/", 404, "", ""},
+		{"no index 403", 403, "index.html", "/assets/", 403, "", ""},
+		{"index disabled", 403, "", "/docs/", 403, "", ""},
+		{"root without index", 404, "index.html", "/", 404, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewApp()
+			a.Router.FS = fsys
+			a.Router.DirStatus = tt.dirStatus
+			a.Router.IndexFile = tt.index
+			w := do(a, httptest.NewRequest("GET", tt.path, nil))
+			if w.Code != tt.status || w.Header().Get("Location") != tt.location {
+				t.Fatalf("status %d, Location %q", w.Code, w.Header().Get("Location"))
+			}
+			// 목록은 어떤 경우에도 노출 안함
+			if !strings.Contains(w.Body.String(), tt.body) || strings.Contains(w.Body.String(), "app.js") {
+				t.Fatalf("body %q", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestServeFSDotfiles(t *testing.T) {
+	fsys := fstest.MapFS{
+		".env":                     {Data: []byte("SECRET=1")},
+		".git/config":              {Data: []byte("[core]")},
+		"a/.hidden/x.txt":          {Data: []byte("x")},
+		".well-known/security.txt": {Data: []byte("contact")},
+		"public/readme.txt":        {Data: []byte("readme")},
+	}
+
+	tests := []struct {
+		path   string
+		hide   bool
+		status int
+	}{
+		{"/.env", true, 404},
+		{"/.git/config", true, 404},
+		{"/a/.hidden/x.txt", true, 404},
+		{"/public/../.env", true, 404},
+		{"/.well-known/security.txt", true, 200},
+		{"/public/readme.txt", true, 200},
+		{"/.env", false, 200},
+	}
+	for _, tt := range tests {
+		a := NewApp()
+		a.Router.FS = fsys
+		a.Router.HideDotfiles = tt.hide
+		if w := do(a, httptest.NewRequest("GET", tt.path, nil)); w.Code != tt.status {
+			t.Errorf("%s (hide %v): status %d", tt.path, tt.hide, w.Code)
+		}
+	}
+}
+
+func TestServeFSSPAFallback(t *testing.T) {
+	a := NewApp()
+	a.Router.FS = fstest.MapFS{
+		"index.html": {Data: []byte("spa shell")},
+		"app.js":     {Data: []byte("app")},
+	}
+	a.Router.SPAFallback = "index.html"
+	a.Router.AddRoute(a, "GET", "/api/users", ReplyJSON)
+
+	tests := []struct {
+		method string
+		path   string
+		status int
+		body   string
+	}{
+		{"GET", "/settings/profile", 200, "spa shell"},
+		{"HEAD", "/settings", 200, ""},
+		{"GET", "/app.js", 200, "app"},
+		{"GET", "/missing.js", 404, ""},
+		{"GET", "/api/unknown", 404, ""},
+		{"POST", "/settings", 404, ""},
+		{"GET", "/api/users", 200, `"Code":"OK"`},
+	}
+	for _, tt := range tests {
+		w := do(a, httptest.NewRequest(tt.method, tt.path, nil))
+		if w.Code != tt.status || !strings.Contains(w.Body.String(), tt.body) {
+			t.Errorf("%s %s: status %d, body %q", tt.method, tt.path, w.Code, w.Body.String())
+		}
+		if tt.status == 404 && strings.Contains(w.Body.String(), "spa shell") {
+			t.Errorf("%s %s: fell back to the SPA shell", tt.method, tt.path)
+		}
+	}
+}
+
+func TestServeFSPrecompressed(t *testing.T) {
+	a := NewApp()
+	a.Router.FS = fstest.MapFS{
+		"app.js":      {Data: []byte("plain")},
+		"app.js.gz":   {Data: []byte("gzip bytes")},
+		"app.js.br":   {Data: []byte("br bytes")},
+		"site.css":    {Data: []byte("css")},
+		"site.css.gz": {Data: []byte("css gzip")},
+	}
+
+	tests := []struct {
+		path     string
+		accept   string
+		encoding string
+		body     string
+	}{
+		{"/app.js", "gzip, br", "br", "br bytes"},
+		{"/app.js", "gzip", "gzip", "gzip bytes"},
+		{"/app.js", "br;q=0, gzip", "gzip", "gzip bytes"},
+		{"/app.js", "*", "br", "br bytes"},
+		{"/app.js", "", "", "plain"},
+		{"/site.css", "br, gzip", "gzip", "css gzip"},
+		{"/site.css", "br", "", "css"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", tt.path, nil)
+		if tt.accept != "" {
+			r.Header.Set("Accept-Encoding", tt.accept)
+		}
+		w := do(a, r)
+		h := w.Header()
+		if h.Get("Content-Encoding") != tt.encoding || w.Body.String() != tt.body {
+			t.Errorf("%s (%q): encoding %q, body %q", tt.path, tt.accept, h.Get("Content-Encoding"), w.Body.String())
+		}
+		// Content-Type 은 원본 이름 기준, 캐시는 인코딩별로 구분
+		if !strings.HasPrefix(h.Get("Content-Type"), mime.TypeByExtension(path.Ext(tt.path))) || h.Get("Vary") != "Accept-Encoding" {
+			t.Errorf("%s (%q): Content-Type %q, Vary %q", tt.path, tt.accept, h.Get("Content-Type"), h.Get("Vary"))
+		}
+	}
+}
+
+func TestServeFSCacheControl(t *testing.T) {
+	a := NewApp()
+	a.Router.FS = fstest.MapFS{
+		"index.html":             {Data: []byte("html")},
+		"app.3f9a1c2e.js":        {Data: []byte("hashed")},
+		"chunk-0123456789ab.css": {Data: []byte("hashed")},
+		"logo.png":               {Data: []byte("png")},
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/", "no-cache"},
+		{"/index.html", "no-cache"},
+		{"/app.3f9a1c2e.js", "public, max-age=31536000, immutable"},
+		{"/chunk-0123456789ab.css", "public, max-age=31536000, immutable"},
+		{"/logo.png", ""},
+	}
+	for _, tt := range tests {
+		if got := do(a, httptest.NewRequest("GET", tt.path, nil)).Header().Get("Cache-Control"); got != tt.want {
+			t.Errorf("%s: Cache-Control %q, want %q", tt.path, got, tt.want)
+		}
+	}
+
+	// 규칙은 앞쪽이 우선
+	a.Router.CacheRules = []CacheRule{
+		{regexp.MustCompile(`\.png$`), "public, max-age=3600"},
+		{regexp.MustCompile(`.*`), "no-store"},
+	}
+	for p, want := range map[string]string{"/logo.png": "public, max-age=3600", "/index.html": "no-store"} {
+		if got := do(a, httptest.NewRequest("GET", p, nil)).Header().Get("Cache-Control"); got != want {
+			t.Errorf("custom rules %s: Cache-Control %q, want %q", p, got, want)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package x
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+	"sync"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// HTML 템플릿
+// /////////////////////////////////////////////////////////////////////////////
+
+/*
+디렉토리 구조 (Dir 기준)
+
+	layouts/*.html   : 레이아웃. 모든 페이지와 함께 파싱됨
+	partials/*.html  : 조각 템플릿. 모든 페이지와 함께 파싱됨
+	errors/<Code>.html, errors/default.html : 에러 페이지
+	그 외 *.html      : 페이지. 이름은 Dir 기준 상대경로 (예: "user/list.html")
+
+페이지는 블록을 정의하고 레이아웃을 호출하는 방식으로 작성
+{{define "content"}}...{{end}}{{template "layouts/base.html" .}}
+
+주의: WebRoot 안에 두면 정적파일로도 노출되므로 별도 디렉토리나 embed.FS 권장
+*/
+type Templates struct {
+	FS     fs.FS
+	Dir    string
+	Funcs  template.FuncMap
+	Reload bool // 개발모드. 렌더링마다 다시 파싱
+
+	mu    sync.RWMutex
+	pages map[string]*template.Template
+}
+
+func NewTemplates(fsys fs.FS, dir string) *Templates {
+	return &Templates{
+		FS:  fsys,
+		Dir: dir,
+		Funcs: template.FuncMap{
+			"csrfField": CSRFField,
+			"csrfMeta":  CSRFMeta,
+		},
+	}
+}
+
+// 전체 템플릿 파싱. 실패 시 기존 템플릿 유지
+func (t *Templates) Load() error {
+	root := path.Clean(t.Dir)
+	var shared, pages []string
+	err := fs.WalkDir(t.FS, root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || path.Ext(p) != ".html" {
+			return nil
+		}
+		rel := strings.TrimPrefix(p, root+"/")
+		if strings.HasPrefix(rel, "layouts/") || strings.HasPrefix(rel, "partials/") {
+			shared = append(shared, p)
+		} else {
+			pages = append(pages, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	base := template.New("").Funcs(t.Funcs)
+	for _, p := range shared {
+		if err := parseFile(base, t.FS, strings.TrimPrefix(p, root+"/"), p); err != nil {
+			return err
+		}
+	}
+
+	set := make(map[string]*template.Template, len(pages))
+	for _, p := range pages {
+		name := strings.TrimPrefix(p, root+"/")
+		tmpl, err := base.Clone()
+		if err != nil {
+			return err
+		}
+		if err := parseFile(tmpl, t.FS, name, p); err != nil {
+			return err
+		}
+		set[name] = tmpl
+	}
+
+	t.mu.Lock()
+	t.pages = set
+	t.mu.Unlock()
+	return nil
+}
+
+func parseFile(t *template.Template, fsys fs.FS, name, p string) error {
+	b, err := fs.ReadFile(fsys, p)
+	if err != nil {
+		return err
+	}
+	if _, err := t.New(name).Parse(string(b)); err != nil {
+		return fmt.Errorf("template %s: %w", p, err)
+	}
+	return nil
+}
+
+// 페이지 존재 여부
+func (t *Templates) Has(name string) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	_, ok := t.pages[name]
+	return ok
+}
+
+// 페이지 렌더링. 중간에 실패해도 일부만 출력되지 않도록 버퍼에 먼저 씀
+func (t *Templates) Render(w io.Writer, name string, data any) error {
+	if t.Reload {
+		if err := t.Load(); err != nil {
+			return err
+		}
+	}
+
+	t.mu.RLock()
+	tmpl, ok := t.pages[name]
+	t.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("template %s not found", name)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, name, data); err != nil {
+		return err
+	}
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// 에러 페이지 이름 결정. errors/<Code>.html → errors/default.html 순
+func (t *Templates) errorPage(code string) string {
+	if t.Reload {
+		t.Load()
+	}
+	for _, name := range []string{"errors/" + code + ".html", "errors/default.html"} {
+		if t.Has(name) {
+			return name
+		}
+	}
+	return ""
+}
+
+/*
+라우트 등록 시 템플릿 지정
+a.Router.AddRoute(a, "GET", "/users", x.ReplyTemplate("user/list.html"), ListUsers)
+*/
+func ReplyTemplate(name string) HandlerFunc {
+	return func(c *Context) {
+		if c.View == "" {
+			c.View = name
+		}
+		ReplyHTML(c)
+	}
+}
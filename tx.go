@@ -0,0 +1,86 @@
+package x
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// 요청 단위 트랜잭션
+// /////////////////////////////////////////////////////////////////////////////
+
+/*
+App.Conns[key] 로 트랜잭션 시작. 같은 요청에서 다시 호출하면 같은 트랜잭션 반환
+요청 컨텍스트에 묶여 있어서 클라이언트가 끊으면 드라이버가 롤백함
+응답 직전에 AppError 가 "OK" 면 커밋, 그 외(에러, panic)는 롤백
+커밋이 실패하면 응답은 RuntimeError 로 바뀜
+
+	func CreateOrder(c *x.Context) {
+		tx := c.Tx("db1")
+		tx.Exec("INSERT ...")
+	}
+
+격리 수준, 읽기 전용은 라우트별로 지정
+a.Router.AddRoute(a, "GET", "/report", x.ReplyJSON, Report).TxOptions = &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}
+*/
+func (c *Context) Tx(key string) *sql.Tx {
+	if tx, ok := c.txs[key]; ok {
+		return tx
+	}
+	db := c.App.GetConn(key)
+	if db == nil {
+		panic(fmt.Errorf("unknown connection: %s", key))
+	}
+
+	var opts *sql.TxOptions
+	if c.Route != nil {
+		opts = c.Route.TxOptions
+	}
+	tx, err := db.BeginTx(c.Req.Context(), opts)
+	if err != nil {
+		panic(err)
+	}
+
+	if c.txs == nil {
+		c.txs = map[string]*sql.Tx{}
+		c.BeforeReply(c.endTx)
+		// BeforeReply 전에 끝나는 경우 대비. 이미 끝난 트랜잭션이면 아무것도 안함
+		c.Defer(c.rollbackTx)
+	}
+	c.txs[key] = tx
+	c.txOrder = append(c.txOrder, key)
+	return tx
+}
+
+// 시작한 순서대로 커밋. 하나라도 실패하면 나머지는 롤백
+func (c *Context) endTx() {
+	if c.AppError.Code != "OK" {
+		c.rollbackTx()
+		return
+	}
+	for i, key := range c.txOrder {
+		if err := c.txs[key].Commit(); err != nil {
+			c.App.Logger.Error("tx commit failed", key, err)
+			for _, rest := range c.txOrder[i+1:] {
+				c.txs[rest].Rollback()
+			}
+			if i > 0 {
+				// 앞의 커넥션은 이미 커밋됨 (분산 트랜잭션 아님)
+				c.App.Logger.Error("tx partially committed", c.txOrder[:i])
+			}
+			c.AppError = NewAppError("RuntimeError", fmt.Errorf("commit %s: %w", key, err), nil)
+			c.Response.Code = c.AppError.Code
+			c.reportError(c.AppError, nil)
+			return
+		}
+	}
+}
+
+func (c *Context) rollbackTx() {
+	for _, key := range c.txOrder {
+		if err := c.txs[key].Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			c.App.Logger.Warn("tx rollback failed", key, err)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package x
+
+import (
+	"database/sql"
+	"errors"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestTx(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler func(c *Context)
+		code    string
+		log     []string
+	}{
+		{"commit", func(c *Context) {
+			c.Tx("db").Exec("INSERT 1")
+		}, "OK", []string{"begin", "exec (tx) INSERT 1 0", "commit"}},
+		{"same tx", func(c *Context) {
+			c.Tx("db").Exec("INSERT 1")
+			c.Tx("db").Exec("INSERT 2")
+		}, "OK", []string{"begin", "exec (tx) INSERT 1 0", "exec (tx) INSERT 2 0", "commit"}},
+		{"app error", func(c *Context) {
+			c.Tx("db").Exec("INSERT 1")
+			NewAppError(CodeConflict, nil, nil).Panic()
+		}, CodeConflict, []string{"begin", "exec (tx) INSERT 1 0", "rollback"}},
+		{"panic", func(c *Context) {
+			c.Tx("db").Exec("INSERT 1")
+			panic("boom")
+		}, "RuntimeError", []string{"begin", "exec (tx) INSERT 1 0", "rollback"}},
+		{"returned error", func(c *Context) {
+			E(func(c *Context) error {
+				c.Tx("db").Exec("INSERT 1")
+				return errors.New("failed")
+			})(c)
+		}, "RuntimeError", []string{"begin", "exec (tx) INSERT 1 0", "rollback"}},
+		{"no tx", func(c *Context) {}, "OK", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub, db := newStubDB()
+			a := NewApp()
+			a.Conns["db"] = db
+			a.Router.AddRoute(a, "POST", "/tx", ReplyJSON, tt.handler)
+
+			body := serve(a, httptest.NewRequest("POST", "/tx", nil))
+			if !strings.Contains(body, `"Code":"`+tt.code+`"`) {
+				t.Fatalf("body %q, want %s", body, tt.code)
+			}
+			if log := stub.Log(); !slices.Equal(log, tt.log) {
+				t.Fatalf("log %q, want %q", log, tt.log)
+			}
+		})
+	}
+}
+
+func TestTxCommitFailure(t *testing.T) {
+	first, db1 := newStubDB()
+	second, db2 := newStubDB()
+	second.commitErr = errors.New("deadlock")
+	third, db3 := newStubDB()
+	reporter := &countingReporter{}
+
+	a := NewApp()
+	a.Reporter = reporter
+	a.Conns["db1"], a.Conns["db2"], a.Conns["db3"] = db1, db2, db3
+	a.Router.AddRoute(a, "POST", "/tx", ReplyJSON, func(c *Context) {
+		for _, key := range []string{"db1", "db2", "db3"} {
+			c.Tx(key).Exec("INSERT " + key)
+		}
+	})
+
+	body := serve(a, httptest.NewRequest("POST", "/tx", nil))
+	if !strings.Contains(body, `"Code":"RuntimeError"`) {
+		t.Fatalf("body %q", body)
+	}
+	// 시작한 순서대로 커밋하고, 실패한 뒤의 트랜잭션은 롤백
+	for stub, want := range map[*stubDB]string{first: "commit", second: "commit", third: "rollback"} {
+		if log := stub.Log(); log[len(log)-1] != want {
+			t.Errorf("log %q, want %s last", log, want)
+		}
+	}
+	if got := reporter.codes; !slices.Equal(got, []string{"RuntimeError"}) {
+		t.Fatalf("reported %v", got)
+	}
+}
+
+func TestTxOptions(t *testing.T) {
+	stub, db := newStubDB()
+	a := NewApp()
+	a.Conns["db"] = db
+	a.Router.AddRoute(a, "GET", "/report", ReplyJSON, func(c *Context) {
+		c.Tx("db").Exec("SELECT 1")
+	}).TxOptions = &sql.TxOptions{ReadOnly: true}
+
+	serve(a, httptest.NewRequest("GET", "/report", nil))
+	if log := stub.Log(); len(log) == 0 || log[0] != "begin read only" {
+		t.Fatalf("log %q", log)
+	}
+}
@@ -0,0 +1,18 @@
+package util
+
+import "testing"
+
+// /////////////////////////////////////////////////////////////////////////////
+// 테스트 공용 도우미
+// /////////////////////////////////////////////////////////////////////////////
+
+func newTestKeyring(t *testing.T, ids ...string) *Keyring {
+	t.Helper()
+	kr := NewKeyring()
+	for _, id := range ids {
+		if err := kr.AddDerived(id, []byte("master-"+id)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return kr
+}
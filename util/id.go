@@ -0,0 +1,296 @@
+package util
+
+import (
+	crand "crypto/rand"
+	"database/sql/driver"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// 시간순 식별자 (UUIDv7, ULID)
+// /////////////////////////////////////////////////////////////////////////////
+/*
+앞 48비트가 unix ms 라서 생성 순서대로 정렬됨 (DB 기본키로 쓰면 인덱스 단편화가 적음)
+같은 ms 안에서는 무작위 부분을 1씩 증가시켜 순서 보장. 여러 고루틴에서 동시에 호출해도 안전
+시계가 뒤로 가도 마지막 시각을 유지하므로 순서가 역전되지 않음
+*/
+
+type monotonic struct {
+	mu     sync.Mutex
+	ms     int64
+	hi, lo uint64
+	hiMask uint64
+	loMask uint64
+}
+
+func (m *monotonic) reseed() {
+	var b [16]byte
+	if _, err := crand.Read(b[:]); err != nil {
+		panic(fmt.Errorf("crypto/rand failed: %w", err))
+	}
+	m.hi = binary.BigEndian.Uint64(b[:8]) & m.hiMask
+	m.lo = binary.BigEndian.Uint64(b[8:]) & m.loMask
+}
+
+func (m *monotonic) next() (int64, uint64, uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if now := time.Now().UnixMilli(); now > m.ms {
+		m.ms = now
+		m.reseed()
+		return m.ms, m.hi, m.lo
+	}
+	m.lo = (m.lo + 1) & m.loMask
+	if m.lo == 0 {
+		m.hi = (m.hi + 1) & m.hiMask
+		if m.hi == 0 {
+			// 같은 ms 안에서 증가분을 다 쓴 경우 (사실상 발생하지 않음) 다음 ms 로 넘김
+			m.ms++
+			m.reseed()
+		}
+	}
+	return m.ms, m.hi, m.lo
+}
+
+func putMillis(b []byte, ms int64) {
+	b[0] = byte(ms >> 40)
+	b[1] = byte(ms >> 32)
+	b[2] = byte(ms >> 24)
+	b[3] = byte(ms >> 16)
+	b[4] = byte(ms >> 8)
+	b[5] = byte(ms)
+}
+
+func getMillis(b []byte) time.Time {
+	ms := int64(b[0])<<40 | int64(b[1])<<32 | int64(b[2])<<24 |
+		int64(b[3])<<16 | int64(b[4])<<8 | int64(b[5])
+	return time.UnixMilli(ms)
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// UUID (RFC 9562)
+// /////////////////////////////////////////////////////////////////////////////
+
+type UUIDValue [16]byte
+
+var ErrInvalidUUID = errors.New("invalid uuid")
+
+// rand_a 12비트 + rand_b 62비트를 하나의 카운터로 사용 (RFC 9562 6.2 Method 1)
+var uuidGen = &monotonic{hiMask: 0xfff, loMask: 1<<62 - 1}
+
+func NewUUIDv7() UUIDValue {
+	ms, hi, lo := uuidGen.next()
+	var u UUIDValue
+	putMillis(u[:], ms)
+	binary.BigEndian.PutUint16(u[6:8], 0x7000|uint16(hi))
+	binary.BigEndian.PutUint64(u[8:], 0x8000000000000000|lo)
+	return u
+}
+
+// 하이픈 포함 소문자 문자열
+func UUIDv7() string {
+	return NewUUIDv7().String()
+}
+
+func (u UUIDValue) String() string {
+	var buf [36]byte
+	hex.Encode(buf[0:8], u[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], u[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], u[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], u[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], u[10:])
+	return string(buf[:])
+}
+
+func (u UUIDValue) Version() int { return int(u[6] >> 4) }
+
+// v7 의 생성 시각. 다른 버전이면 zero time
+func (u UUIDValue) Time() time.Time {
+	if u.Version() != 7 {
+		return time.Time{}
+	}
+	return getMillis(u[:])
+}
+
+/*
+"0190a6e4-...-..." (36자), 하이픈 없는 32자, "urn:uuid:" 접두사 허용
+RFC 9562 variant 가 아니면 ErrInvalidUUID
+*/
+func ParseUUID(s string) (UUIDValue, error) {
+	var u UUIDValue
+	s = strings.TrimPrefix(strings.ToLower(s), "urn:uuid:")
+	switch len(s) {
+	case 36:
+		if s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+			return u, ErrInvalidUUID
+		}
+		s = s[0:8] + s[9:13] + s[14:18] + s[19:23] + s[24:]
+	case 32:
+	default:
+		return u, ErrInvalidUUID
+	}
+	if _, err := hex.Decode(u[:], []byte(s)); err != nil {
+		return u, ErrInvalidUUID
+	}
+	if u[8]&0xc0 != 0x80 {
+		return u, ErrInvalidUUID
+	}
+	return u, nil
+}
+
+func IsUUID(s string) bool {
+	_, err := ParseUUID(s)
+	return err == nil
+}
+
+func (u UUIDValue) MarshalText() ([]byte, error) { return []byte(u.String()), nil }
+
+func (u *UUIDValue) UnmarshalText(b []byte) error {
+	v, err := ParseUUID(string(b))
+	if err != nil {
+		return err
+	}
+	*u = v
+	return nil
+}
+
+// CHAR(36) 컬럼용. BINARY(16) 컬럼에는 u[:] 를 넘길것
+func (u UUIDValue) Value() (driver.Value, error) { return u.String(), nil }
+
+// 문자열 또는 16바이트 BINARY 컬럼 모두 지원
+func (u *UUIDValue) Scan(src any) error {
+	switch v := src.(type) {
+	case []byte:
+		if len(v) == 16 {
+			copy(u[:], v)
+			return nil
+		}
+		return u.UnmarshalText(v)
+	case string:
+		return u.UnmarshalText([]byte(v))
+	}
+	return fmt.Errorf("cannot scan %T into UUIDValue", src)
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// ULID
+// /////////////////////////////////////////////////////////////////////////////
+
+type ULID [16]byte
+
+var ErrInvalidULID = errors.New("invalid ulid")
+
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+var crockfordDec = func() [256]byte {
+	var t [256]byte
+	for i := range t {
+		t[i] = 0xff
+	}
+	for i := 0; i < len(crockford); i++ {
+		t[crockford[i]] = byte(i)
+		t[strings.ToLower(crockford)[i]] = byte(i)
+	}
+	// 혼동하기 쉬운 문자
+	for _, c := range "oO" {
+		t[c] = 0
+	}
+	for _, c := range "iIlL" {
+		t[c] = 1
+	}
+	return t
+}()
+
+var ulidGen = &monotonic{hiMask: 0xffff, loMask: 1<<64 - 1}
+
+func NewULID() ULID {
+	ms, hi, lo := ulidGen.next()
+	var u ULID
+	putMillis(u[:], ms)
+	binary.BigEndian.PutUint16(u[6:8], uint16(hi))
+	binary.BigEndian.PutUint64(u[8:], lo)
+	return u
+}
+
+// 26자 Crockford base32 문자열
+func ULIDString() string {
+	return NewULID().String()
+}
+
+func (u ULID) String() string {
+	hi := binary.BigEndian.Uint64(u[:8])
+	lo := binary.BigEndian.Uint64(u[8:])
+	var buf [26]byte
+	for i := 25; i >= 0; i-- {
+		buf[i] = crockford[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(buf[:])
+}
+
+func (u ULID) Time() time.Time { return getMillis(u[:]) }
+
+func ParseULID(s string) (ULID, error) {
+	var u ULID
+	// 128비트라서 첫 글자는 3비트(0~7)만 사용
+	if len(s) != 26 || crockfordDec[s[0]] > 7 {
+		return u, ErrInvalidULID
+	}
+	var hi, lo uint64
+	for i := 0; i < 26; i++ {
+		v := crockfordDec[s[i]]
+		if v == 0xff {
+			return u, ErrInvalidULID
+		}
+		hi = hi<<5 | lo>>59
+		lo = lo<<5 | uint64(v)
+	}
+	binary.BigEndian.PutUint64(u[:8], hi)
+	binary.BigEndian.PutUint64(u[8:], lo)
+	return u, nil
+}
+
+func IsULID(s string) bool {
+	_, err := ParseULID(s)
+	return err == nil
+}
+
+func (u ULID) MarshalText() ([]byte, error) { return []byte(u.String()), nil }
+
+func (u *ULID) UnmarshalText(b []byte) error {
+	v, err := ParseULID(string(b))
+	if err != nil {
+		return err
+	}
+	*u = v
+	return nil
+}
+
+// CHAR(26) 컬럼용. BINARY(16) 컬럼에는 u[:] 를 넘길것
+func (u ULID) Value() (driver.Value, error) { return u.String(), nil }
+
+func (u *ULID) Scan(src any) error {
+	switch v := src.(type) {
+	case []byte:
+		if len(v) == 16 {
+			copy(u[:], v)
+			return nil
+		}
+		return u.UnmarshalText(v)
+	case string:
+		return u.UnmarshalText([]byte(v))
+	}
+	return fmt.Errorf("cannot scan %T into ULID", src)
+}
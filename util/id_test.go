@@ -0,0 +1,151 @@
+package util
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestULIDString(t *testing.T) {
+	full := ULID{}
+	for i := range full {
+		full[i] = 0xff
+	}
+	tests := []struct {
+		id   ULID
+		want string
+	}{
+		{ULID{}, "00000000000000000000000000"},
+		{ULID{15: 1}, "00000000000000000000000001"},
+		{ULID{15: 0x1f}, "0000000000000000000000000Z"},
+		{ULID{15: 0x20}, "00000000000000000000000010"},
+		{ULID{0: 0x80}, "40000000000000000000000000"},
+		{full, "7ZZZZZZZZZZZZZZZZZZZZZZZZZ"},
+	}
+	for _, tt := range tests {
+		if got := tt.id.String(); got != tt.want {
+			t.Errorf("%x: String = %s, want %s", tt.id[:], got, tt.want)
+		}
+		if got, err := ParseULID(tt.want); err != nil || got != tt.id {
+			t.Errorf("ParseULID(%s) = %x, %v", tt.want, got[:], err)
+		}
+	}
+}
+
+func TestParseULID(t *testing.T) {
+	// 소문자와 혼동 문자(O→0, I/L→1) 허용
+	aliases := map[string]string{
+		"0000000000000000000000000z": "0000000000000000000000000Z",
+		"000000000000000000000000oO": "00000000000000000000000000",
+		"000000000000000000000000iL": "00000000000000000000000011",
+	}
+	for in, want := range aliases {
+		u, err := ParseULID(in)
+		if err != nil || u.String() != want {
+			t.Errorf("ParseULID(%s) = %s, %v", in, u, err)
+		}
+	}
+
+	for _, bad := range []string{
+		"",
+		"0000000000000000000000000",   // 25자
+		"000000000000000000000000000", // 27자
+		"80000000000000000000000000",  // 128비트 초과
+		"0000000000000000000000000U",
+		"0000000000000000000000000-",
+	} {
+		if _, err := ParseULID(bad); !errors.Is(err, ErrInvalidULID) {
+			t.Errorf("ParseULID(%q) = %v", bad, err)
+		}
+	}
+
+	u := NewULID()
+	var back ULID
+	if err := back.UnmarshalText([]byte(u.String())); err != nil || back != u {
+		t.Fatalf("round trip %s = %s, %v", u, back, err)
+	}
+	if d := time.Since(u.Time()); d < 0 || d > time.Minute {
+		t.Fatalf("Time = %v", u.Time())
+	}
+}
+
+// RFC 9562 부록 A.6
+func TestParseUUID(t *testing.T) {
+	const example = "017f22e2-79b0-7cc3-98c4-dc0c0c07398f"
+	for _, in := range []string{example, "017F22E2-79B0-7CC3-98C4-DC0C0C07398F", "urn:uuid:" + example, "017f22e279b07cc398c4dc0c0c07398f"} {
+		u, err := ParseUUID(in)
+		if err != nil || u.String() != example {
+			t.Fatalf("ParseUUID(%s) = %s, %v", in, u, err)
+		}
+		if u.Version() != 7 || !u.Time().Equal(time.UnixMilli(0x017F22E279B0)) {
+			t.Fatalf("version %d, time %v", u.Version(), u.Time())
+		}
+	}
+
+	for _, bad := range []string{
+		"",
+		"017f22e2-79b0-7cc3-98c4-dc0c0c07398",
+		"017f22e2_79b0-7cc3-98c4-dc0c0c07398f",
+		"017f22e2-79b0-7cc3-18c4-dc0c0c07398f", // variant
+		"g17f22e2-79b0-7cc3-98c4-dc0c0c07398f",
+	} {
+		if _, err := ParseUUID(bad); !errors.Is(err, ErrInvalidUUID) {
+			t.Errorf("ParseUUID(%q) = %v", bad, err)
+		}
+	}
+
+	u := NewUUIDv7()
+	if back, err := ParseUUID(u.String()); err != nil || back != u || u.Version() != 7 {
+		t.Fatalf("round trip %s = %s, %v", u, back, err)
+	}
+}
+
+func TestIDsAreOrdered(t *testing.T) {
+	const n = 10000
+	prevU, prevL := NewUUIDv7(), NewULID()
+	sameMs := 0
+	for range n {
+		u, l := NewUUIDv7(), NewULID()
+		if bytes.Compare(u[:], prevU[:]) <= 0 || u.String() <= prevU.String() {
+			t.Fatalf("UUIDv7 %s after %s", u, prevU)
+		}
+		if bytes.Compare(l[:], prevL[:]) <= 0 || l.String() <= prevL.String() {
+			t.Fatalf("ULID %s after %s", l, prevL)
+		}
+		if l.Time().Equal(prevL.Time()) {
+			sameMs++
+		}
+		prevU, prevL = u, l
+	}
+	if sameMs == 0 {
+		t.Fatal("no ids generated within the same millisecond")
+	}
+}
+
+func TestMonotonicSameMillisecond(t *testing.T) {
+	// 시계가 뒤에 있는 상황(같은 ms 또는 시계 역행)을 미래 시각으로 재현
+	future := time.Now().Add(time.Hour).UnixMilli()
+	m := &monotonic{hiMask: 0xf, loMask: 0xff}
+	m.ms, m.hi, m.lo = future, 3, 0xfe
+
+	steps := []struct {
+		ms     int64
+		hi, lo uint64
+	}{
+		{future, 3, 0xff},
+		{future, 4, 0}, // lo 넘침은 hi 로 올림
+	}
+	for i, want := range steps {
+		ms, hi, lo := m.next()
+		if ms != want.ms || hi != want.hi || lo != want.lo {
+			t.Fatalf("step %d = %d, %d, %d", i, ms, hi, lo)
+		}
+	}
+
+	// 증가분을 다 쓰면 다음 ms 로
+	m.hi, m.lo = 0xf, 0xff
+	if ms, _, _ := m.next(); ms != future+1 {
+		t.Fatalf("overflow ms = %d, want %d", ms, future+1)
+	}
+}
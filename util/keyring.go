@@ -0,0 +1,440 @@
+package util
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	crand "crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// 키링 : 키 ID 헤더가 붙은 AES-GCM, 키 교체
+// /////////////////////////////////////////////////////////////////////////////
+/*
+암호문 앞에 키 ID 를 붙여서 어떤 키로 암호화했는지 알 수 있게 함
+새 키를 추가하고 Primary 로 지정하면 이후 암호화는 새 키로, 복호화는 등록된 모든 키로 가능
+기존 데이터는 Reencrypt 로 천천히 옮기고 다 옮긴 뒤 이전 키를 Remove
+
+	kr := util.NewKeyring()
+	kr.AddDerived("2024-01", master) // 또는 kr.Add("2024-01", key32)
+	kr.SetPrimary("2024-01")
+	ct, _ := kr.Encrypt([]byte("secret"), []byte("user:42")) // ad: 암호문을 특정 레코드에 묶음
+
+포맷 (단일 메시지):
+
+	0x01 | len(id) | id | nonce(12) | ciphertext+tag
+
+헤더 전체가 GCM 추가 인증 데이터에 포함되므로 키 ID 를 바꿔치기 할 수 없음
+*/
+
+const (
+	keyringVersion       = 0x01
+	keyringStreamVersion = 0x02
+	streamChunkSize      = 64 << 10
+	streamPrefixSize     = 7 // nonce = prefix(7) | counter(4) | last(1)
+)
+
+var (
+	ErrUnknownKey      = errors.New("keyring: unknown key id")
+	ErrNoPrimaryKey    = errors.New("keyring: no primary key")
+	ErrInvalidCipher   = errors.New("keyring: invalid ciphertext")
+	ErrStreamTruncated = errors.New("keyring: stream truncated")
+	errStreamClosed    = errors.New("keyring: write after close")
+)
+
+type Keyring struct {
+	mu      sync.RWMutex
+	keys    map[string]cipher.AEAD
+	primary string
+}
+
+func NewKeyring() *Keyring {
+	return &Keyring{keys: map[string]cipher.AEAD{}}
+}
+
+// 키 추가 (16/24/32바이트). 처음 추가한 키는 자동으로 Primary
+func (k *Keyring) Add(id string, key []byte) error {
+	if id == "" || len(id) > 255 {
+		return errors.New("keyring: key id must be 1..255 bytes")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return err
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.keys[id] = aead
+	if k.primary == "" {
+		k.primary = id
+	}
+	return nil
+}
+
+// 마스터 비밀에서 키 ID 별 32바이트 키를 유도해서 추가
+func (k *Keyring) AddDerived(id string, master []byte) error {
+	key, err := DeriveKey(master, nil, "x/keyring:"+id, 32)
+	if err != nil {
+		return err
+	}
+	return k.Add(id, key)
+}
+
+func (k *Keyring) SetPrimary(id string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if _, ok := k.keys[id]; !ok {
+		return ErrUnknownKey
+	}
+	k.primary = id
+	return nil
+}
+
+func (k *Keyring) Primary() string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.primary
+}
+
+// 폐기. 이 키로 암호화된 데이터는 더 이상 복호화되지 않음
+func (k *Keyring) Remove(id string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	delete(k.keys, id)
+	if k.primary == id {
+		k.primary = ""
+	}
+}
+
+func (k *Keyring) primaryKey() (string, cipher.AEAD, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	if k.primary == "" {
+		return "", nil, ErrNoPrimaryKey
+	}
+	return k.primary, k.keys[k.primary], nil
+}
+
+func (k *Keyring) key(id string) (cipher.AEAD, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	aead, ok := k.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, id)
+	}
+	return aead, nil
+}
+
+func keyringHeader(version byte, id string) []byte {
+	h := make([]byte, 0, 2+len(id)+12)
+	h = append(h, version, byte(len(id)))
+	return append(h, id...)
+}
+
+// 헤더를 읽어서 (버전, 키 ID, 헤더 길이) 반환
+func parseKeyringHeader(b []byte) (byte, string, int, error) {
+	if len(b) < 2 || (b[0] != keyringVersion && b[0] != keyringStreamVersion) {
+		return 0, "", 0, ErrInvalidCipher
+	}
+	n := 2 + int(b[1])
+	if b[1] == 0 || len(b) < n {
+		return 0, "", 0, ErrInvalidCipher
+	}
+	return b[0], string(b[2:n]), n, nil
+}
+
+// 암호문의 키 ID
+func KeyIDOf(ciphertext []byte) (string, error) {
+	_, id, _, err := parseKeyringHeader(ciphertext)
+	return id, err
+}
+
+func (k *Keyring) Encrypt(plaintext, ad []byte) ([]byte, error) {
+	id, aead, err := k.primaryKey()
+	if err != nil {
+		return nil, err
+	}
+	header := keyringHeader(keyringVersion, id)
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := crand.Read(nonce); err != nil {
+		return nil, err
+	}
+	out := append(header, nonce...)
+	return aead.Seal(out, nonce, plaintext, append(header[:len(header):len(header)], ad...)), nil
+}
+
+func (k *Keyring) Decrypt(ciphertext, ad []byte) ([]byte, error) {
+	version, id, n, err := parseKeyringHeader(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	if version != keyringVersion {
+		return nil, ErrInvalidCipher
+	}
+	aead, err := k.key(id)
+	if err != nil {
+		return nil, err
+	}
+	rest := ciphertext[n:]
+	if len(rest) < aead.NonceSize() {
+		return nil, ErrInvalidCipher
+	}
+	header := ciphertext[:n:n]
+	return aead.Open(nil, rest[:aead.NonceSize()], rest[aead.NonceSize():], append(header, ad...))
+}
+
+// base64 문자열 버전 (DB 텍스트 컬럼, 쿠키 등)
+func (k *Keyring) EncryptString(plaintext string, ad []byte) (string, error) {
+	b, err := k.Encrypt([]byte(plaintext), ad)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
+func (k *Keyring) DecryptString(ciphertext string, ad []byte) (string, error) {
+	b, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", err
+	}
+	plain, err := k.Decrypt(b, ad)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
+
+/*
+Primary 키로 다시 암호화. 이미 Primary 키면 그대로 반환하고 changed=false
+배치 작업에서 changed 인 행만 UPDATE 하면 됨
+*/
+func (k *Keyring) Reencrypt(ciphertext, ad []byte) (out []byte, changed bool, err error) {
+	id, err := KeyIDOf(ciphertext)
+	if err != nil {
+		return nil, false, err
+	}
+	if id == k.Primary() {
+		return ciphertext, false, nil
+	}
+	plain, err := k.Decrypt(ciphertext, ad)
+	if err != nil {
+		return nil, false, err
+	}
+	out, err = k.Encrypt(plain, ad)
+	return out, err == nil, err
+}
+
+func (k *Keyring) ReencryptString(ciphertext string, ad []byte) (string, bool, error) {
+	b, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", false, err
+	}
+	out, changed, err := k.Reencrypt(b, ad)
+	if err != nil || !changed {
+		return ciphertext, false, err
+	}
+	return base64.StdEncoding.EncodeToString(out), true, nil
+}
+
+// AESGCMEncrypt 로 만든 기존 암호문을 키링 포맷으로 옮김
+func (k *Keyring) ImportLegacy(cipherTextBase64 string, legacyKey, ad []byte) (string, error) {
+	plain, err := AESGCMDecrypt(cipherTextBase64, legacyKey)
+	if err != nil {
+		return "", err
+	}
+	return k.EncryptString(plain, ad)
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// 스트리밍 암호화 (큰 파일)
+// /////////////////////////////////////////////////////////////////////////////
+/*
+64KiB 단위로 나눠서 각각 GCM 으로 암호화. 청크 번호와 마지막 여부가 nonce 에 들어가므로
+순서 변경, 중간 삭제, 뒷부분 잘림이 모두 검출됨
+
+	0x02 | len(id) | id | prefix(7) | chunk... (각 청크 = 암호문 + tag 16)
+
+	w, _ := kr.EncryptWriter(file, nil)
+	io.Copy(w, src)
+	w.Close() // 마지막 청크 기록. 반드시 호출
+*/
+
+func streamNonce(prefix []byte, counter uint32, last bool) []byte {
+	nonce := make([]byte, 12)
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint32(nonce[streamPrefixSize:], counter)
+	if last {
+		nonce[11] = 1
+	}
+	return nonce
+}
+
+type streamWriter struct {
+	w       io.Writer
+	aead    cipher.AEAD
+	ad      []byte
+	prefix  []byte
+	counter uint32
+	buf     []byte
+	closed  bool
+}
+
+func (k *Keyring) EncryptWriter(w io.Writer, ad []byte) (io.WriteCloser, error) {
+	id, aead, err := k.primaryKey()
+	if err != nil {
+		return nil, err
+	}
+	header := keyringHeader(keyringStreamVersion, id)
+	prefix := make([]byte, streamPrefixSize)
+	if _, err := crand.Read(prefix); err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(append(header, prefix...)); err != nil {
+		return nil, err
+	}
+	return &streamWriter{
+		w:      w,
+		aead:   aead,
+		ad:     append(header[:len(header):len(header)], ad...),
+		prefix: prefix,
+		buf:    make([]byte, 0, streamChunkSize),
+	}, nil
+}
+
+func (s *streamWriter) seal(chunk []byte, last bool) error {
+	if s.counter == ^uint32(0) {
+		return errors.New("keyring: stream too large")
+	}
+	out := s.aead.Seal(nil, streamNonce(s.prefix, s.counter, last), chunk, s.ad)
+	s.counter++
+	_, err := s.w.Write(out)
+	return err
+}
+
+func (s *streamWriter) Write(p []byte) (int, error) {
+	if s.closed {
+		return 0, errStreamClosed
+	}
+	n := len(p)
+	for len(p) > 0 {
+		// 버퍼가 가득 찬 상태에서 데이터가 더 있을 때만 내보냄 (마지막 청크는 Close 에서)
+		if len(s.buf) == streamChunkSize {
+			if err := s.seal(s.buf, false); err != nil {
+				return 0, err
+			}
+			s.buf = s.buf[:0]
+		}
+		m := min(streamChunkSize-len(s.buf), len(p))
+		s.buf = append(s.buf, p[:m]...)
+		p = p[m:]
+	}
+	return n, nil
+}
+
+func (s *streamWriter) Close() error {
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	return s.seal(s.buf, true)
+}
+
+type streamReader struct {
+	r       *bufio.Reader
+	aead    cipher.AEAD
+	ad      []byte
+	prefix  []byte
+	counter uint32
+	buf     []byte
+	plain   []byte
+	done    bool
+}
+
+func (k *Keyring) DecryptReader(r io.Reader, ad []byte) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	head, err := br.Peek(2)
+	if err != nil {
+		return nil, ErrInvalidCipher
+	}
+	head, err = br.Peek(2 + int(head[1]))
+	if err != nil {
+		return nil, ErrInvalidCipher
+	}
+	version, id, n, err := parseKeyringHeader(head)
+	if err != nil || version != keyringStreamVersion {
+		return nil, ErrInvalidCipher
+	}
+	aead, err := k.key(id)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, n)
+	io.ReadFull(br, header)
+	prefix := make([]byte, streamPrefixSize)
+	if _, err := io.ReadFull(br, prefix); err != nil {
+		return nil, ErrInvalidCipher
+	}
+	return &streamReader{
+		r:      br,
+		aead:   aead,
+		ad:     append(header, ad...),
+		prefix: prefix,
+		buf:    make([]byte, streamChunkSize+aead.Overhead()),
+	}, nil
+}
+
+func (s *streamReader) Read(p []byte) (int, error) {
+	for len(s.plain) == 0 {
+		if s.done {
+			return 0, io.EOF
+		}
+		n, err := io.ReadFull(s.r, s.buf)
+		if err != nil && err != io.ErrUnexpectedEOF {
+			if err == io.EOF {
+				return 0, ErrStreamTruncated
+			}
+			return 0, err
+		}
+		// 뒤에 더 읽을 것이 없으면 마지막 청크여야 함
+		_, peekErr := s.r.Peek(1)
+		last := peekErr == io.EOF
+		plain, err := s.aead.Open(s.buf[:0], streamNonce(s.prefix, s.counter, last), s.buf[:n], s.ad)
+		if err != nil {
+			if last {
+				return 0, ErrStreamTruncated
+			}
+			return 0, ErrInvalidCipher
+		}
+		s.counter++
+		s.plain = plain
+		s.done = last
+	}
+	n := copy(p, s.plain)
+	s.plain = s.plain[n:]
+	return n, nil
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// 키 유도 (HKDF-SHA256)
+// /////////////////////////////////////////////////////////////////////////////
+
+/*
+마스터 비밀에서 용도별 키 유도. info 가 다르면 서로 독립적인 키가 나옴
+sessionKey, _ := util.DeriveKey(master, nil, "session-cookie", 32)
+*/
+func DeriveKey(master, salt []byte, info string, length int) ([]byte, error) {
+	return hkdf.Key(sha256.New, master, salt, info, length)
+}
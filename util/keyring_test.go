@@ -0,0 +1,185 @@
+package util
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestKeyringEncrypt(t *testing.T) {
+	kr := newTestKeyring(t, "k1")
+	ad := []byte("user:42")
+	ct, err := kr.Encrypt([]byte("secret"), ad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, _ := KeyIDOf(ct); id != "k1" {
+		t.Fatalf("KeyIDOf = %q", id)
+	}
+	if plain, err := kr.Decrypt(ct, ad); err != nil || string(plain) != "secret" {
+		t.Fatalf("Decrypt = %q, %v", plain, err)
+	}
+
+	if _, err := kr.Decrypt(ct, []byte("user:43")); err == nil {
+		t.Error("decrypted with different ad")
+	}
+	tampered := bytes.Clone(ct)
+	tampered[len(tampered)-1] ^= 1
+	if _, err := kr.Decrypt(tampered, ad); err == nil {
+		t.Error("decrypted tampered ciphertext")
+	}
+
+	// 같은 키를 다른 ID 로 등록해도 헤더가 인증되므로 ID 를 바꿔치기 할 수 없음
+	key, _ := DeriveKey([]byte("master-k1"), nil, "x/keyring:k1", 32)
+	kr.Add("k2", key)
+	swapped := bytes.Clone(ct)
+	copy(swapped[2:4], "k2")
+	if _, err := kr.Decrypt(swapped, ad); err == nil {
+		t.Error("decrypted with swapped key id")
+	}
+
+	for _, bad := range [][]byte{nil, {0x01}, {0x01, 0}, {0x09, 1, 'a'}, {0x01, 2, 'k', '1', 0}} {
+		if _, err := kr.Decrypt(bad, nil); err == nil {
+			t.Errorf("Decrypt(%x) succeeded", bad)
+		}
+	}
+}
+
+func TestKeyringRotation(t *testing.T) {
+	kr := newTestKeyring(t, "old")
+	s, _ := kr.EncryptString("secret", nil)
+
+	if err := kr.AddDerived("new", []byte("master-new")); err != nil {
+		t.Fatal(err)
+	}
+	if kr.Primary() != "old" {
+		t.Fatalf("primary changed on Add: %q", kr.Primary())
+	}
+	if err := kr.SetPrimary("new"); err != nil {
+		t.Fatal(err)
+	}
+
+	s2, changed, err := kr.ReencryptString(s, nil)
+	if err != nil || !changed {
+		t.Fatalf("ReencryptString: %v, %v", changed, err)
+	}
+	if _, changed, _ := kr.ReencryptString(s2, nil); changed {
+		t.Error("re-encrypted ciphertext already under primary")
+	}
+
+	kr.Remove("old")
+	if _, err := kr.DecryptString(s, nil); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("old ciphertext after Remove: %v", err)
+	}
+	if plain, err := kr.DecryptString(s2, nil); err != nil || plain != "secret" {
+		t.Errorf("DecryptString = %q, %v", plain, err)
+	}
+
+	kr.Remove("new")
+	if _, err := kr.Encrypt(nil, nil); !errors.Is(err, ErrNoPrimaryKey) {
+		t.Errorf("Encrypt without primary: %v", err)
+	}
+	if err := kr.SetPrimary("missing"); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("SetPrimary(missing): %v", err)
+	}
+}
+
+func TestKeyringImportLegacy(t *testing.T) {
+	legacyKey := bytes.Repeat([]byte{7}, 32)
+	legacy, err := AESGCMEncrypt("secret", legacyKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kr := newTestKeyring(t, "k1")
+	s, err := kr.ImportLegacy(legacy, legacyKey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain, err := kr.DecryptString(s, nil); err != nil || plain != "secret" {
+		t.Fatalf("DecryptString = %q, %v", plain, err)
+	}
+}
+
+func encryptStream(t *testing.T, kr *Keyring, plain, ad []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := kr.EncryptWriter(&buf, ad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 청크 경계와 맞지 않게 나눠서 씀
+	for p := plain; len(p) > 0; {
+		n := min(len(p), 10000)
+		if _, err := w.Write(p[:n]); err != nil {
+			t.Fatal(err)
+		}
+		p = p[n:]
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestKeyringStream(t *testing.T) {
+	kr := newTestKeyring(t, "k1")
+	for _, size := range []int{0, 1, streamChunkSize, streamChunkSize + 1, 3*streamChunkSize + 123} {
+		plain := make([]byte, size)
+		rand.Read(plain)
+		ct := encryptStream(t, kr, plain, []byte("file:1"))
+
+		r, err := kr.DecryptReader(bytes.NewReader(ct), []byte("file:1"))
+		if err != nil {
+			t.Fatalf("size %d: %v", size, err)
+		}
+		got, err := io.ReadAll(r)
+		if err != nil || !bytes.Equal(got, plain) {
+			t.Fatalf("size %d: got %d bytes, %v", size, len(got), err)
+		}
+	}
+}
+
+func TestKeyringStreamTampering(t *testing.T) {
+	kr := newTestKeyring(t, "k1")
+	plain := make([]byte, 2*streamChunkSize+100)
+	ct := encryptStream(t, kr, plain, nil)
+
+	header := 2 + len("k1") + streamPrefixSize
+	chunk := streamChunkSize + 16
+	first, second := ct[header:header+chunk], ct[header+chunk:header+2*chunk]
+
+	tests := map[string][]byte{
+		// 마지막 청크를 통째로 잘라냄
+		"truncated": bytes.Clone(ct[:header+2*chunk]),
+		"reordered": bytes.Join([][]byte{ct[:header], second, first, ct[header+2*chunk:]}, nil),
+		"bit flip": func() []byte {
+			b := bytes.Clone(ct)
+			b[header+10] ^= 1
+			return b
+		}(),
+	}
+	for name, b := range tests {
+		r, err := kr.DecryptReader(bytes.NewReader(b), nil)
+		if err == nil {
+			_, err = io.ReadAll(r)
+		}
+		if err == nil {
+			t.Errorf("%s: decrypted without error", name)
+		}
+	}
+
+	if _, err := io.ReadAll(mustDecryptReader(t, kr, ct[:header+2*chunk])); !errors.Is(err, ErrStreamTruncated) {
+		t.Errorf("truncated: %v, want ErrStreamTruncated", err)
+	}
+}
+
+func mustDecryptReader(t *testing.T, kr *Keyring, b []byte) io.Reader {
+	t.Helper()
+	r, err := kr.DecryptReader(bytes.NewReader(b), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
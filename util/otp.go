@@ -0,0 +1,219 @@
+package util
+
+import (
+	"crypto/hmac"
+	crand "crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"math/big"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// 일회용 코드
+// /////////////////////////////////////////////////////////////////////////////
+
+// n 자리 숫자 코드 (SMS/메일 인증용). 0 으로 시작할 수 있으므로 문자열로 반환
+func RandomDigits(n int) (string, error) {
+	if n <= 0 {
+		return "", nil
+	}
+	max := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+	v, err := crand.Int(crand.Reader, max)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", n, v), nil
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// HOTP (RFC 4226), TOTP (RFC 6238)
+// /////////////////////////////////////////////////////////////////////////////
+
+var otpB32 = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// 새 OTP 비밀키 (base32, 인증앱에 등록할 값). 기본 20바이트
+func NewOTPSecret() (string, error) {
+	b := make([]byte, 20)
+	if _, err := crand.Read(b); err != nil {
+		return "", err
+	}
+	return otpB32.EncodeToString(b), nil
+}
+
+// 공백, 소문자, 패딩이 섞인 base32 비밀키도 허용
+func DecodeOTPSecret(s string) ([]byte, error) {
+	s = strings.ToUpper(strings.ReplaceAll(s, " ", ""))
+	return otpB32.DecodeString(strings.TrimRight(s, "="))
+}
+
+func otpHash(alg string) (func() hash.Hash, error) {
+	switch strings.ToUpper(alg) {
+	case "", "SHA1":
+		return sha1.New, nil
+	case "SHA256":
+		return sha256.New, nil
+	case "SHA512":
+		return sha512.New, nil
+	}
+	return nil, errors.New("unsupported otp algorithm: " + alg)
+}
+
+func hotp(h func() hash.Hash, secret []byte, counter uint64, digits int) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+	m := hmac.New(h, secret)
+	m.Write(msg[:])
+	sum := m.Sum(nil)
+
+	// dynamic truncation
+	off := sum[len(sum)-1] & 0x0f
+	bin := binary.BigEndian.Uint32(sum[off:off+4]) & 0x7fffffff
+	mod := uint32(1)
+	for i := 0; i < digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", digits, bin%mod)
+}
+
+// HOTP 코드 (SHA1)
+func HOTP(secret []byte, counter uint64, digits int) string {
+	return hotp(sha1.New, secret, counter, digits)
+}
+
+/*
+HOTP 검증. counter 부터 counter+window 까지 확인
+성공하면 다음에 저장할 카운터(일치한 카운터+1) 반환
+*/
+func VerifyHOTP(secret []byte, code string, counter uint64, window int, digits int) (uint64, bool) {
+	if len(code) != digits {
+		return counter, false
+	}
+	for i := 0; i <= window; i++ {
+		if subtle.ConstantTimeCompare([]byte(HOTP(secret, counter+uint64(i), digits)), []byte(code)) == 1 {
+			return counter + uint64(i) + 1, true
+		}
+	}
+	return counter, false
+}
+
+/*
+TOTP 설정. 대부분의 인증앱은 SHA1, 6자리, 30초만 지원하므로 기본값 유지 권장
+
+	t := util.NewTOTP(secret)
+	uri := t.URI("MyService", "user@example.com") // QR 코드로 표시
+	step, ok := t.Verify(code, time.Now())         // step 을 저장해서 같은 코드 재사용 차단
+*/
+type TOTP struct {
+	Secret    []byte
+	Digits    int
+	Period    time.Duration
+	Skew      int    // 앞뒤로 허용할 주기 수 (시계 오차)
+	Algorithm string // SHA1, SHA256, SHA512
+}
+
+func NewTOTP(secret []byte) *TOTP {
+	return &TOTP{Secret: secret, Digits: 6, Period: 30 * time.Second, Skew: 1, Algorithm: "SHA1"}
+}
+
+func (t *TOTP) step(tm time.Time) int64 {
+	return tm.Unix() / int64(t.Period/time.Second)
+}
+
+func (t *TOTP) At(tm time.Time) (string, error) {
+	h, err := otpHash(t.Algorithm)
+	if err != nil {
+		return "", err
+	}
+	return hotp(h, t.Secret, uint64(t.step(tm)), t.Digits), nil
+}
+
+/*
+코드 검증. 성공하면 일치한 주기(step) 반환
+호출 측에서 마지막으로 사용된 step 이하의 코드는 거부해야 재사용 공격을 막을 수 있음
+*/
+func (t *TOTP) Verify(code string, tm time.Time) (int64, bool) {
+	h, err := otpHash(t.Algorithm)
+	if err != nil || len(code) != t.Digits {
+		return 0, false
+	}
+	now := t.step(tm)
+	matched, ok := int64(0), false
+	// 일치 여부와 관계없이 모든 주기를 계산 (타이밍 차이 방지)
+	for d := -t.Skew; d <= t.Skew; d++ {
+		s := now + int64(d)
+		if s < 0 {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(hotp(h, t.Secret, uint64(s), t.Digits)), []byte(code)) == 1 && !ok {
+			matched, ok = s, true
+		}
+	}
+	return matched, ok
+}
+
+// 인증앱 등록용 otpauth:// URI (Key Uri Format)
+func (t *TOTP) URI(issuer, account string) string {
+	label := url.PathEscape(account)
+	if issuer != "" {
+		label = url.PathEscape(issuer) + ":" + label
+	}
+	q := url.Values{}
+	q.Set("secret", otpB32.EncodeToString(t.Secret))
+	if issuer != "" {
+		q.Set("issuer", issuer)
+	}
+	q.Set("algorithm", strings.ToUpper(t.Algorithm))
+	q.Set("digits", strconv.Itoa(t.Digits))
+	q.Set("period", strconv.Itoa(int(t.Period/time.Second)))
+	return "otpauth://totp/" + label + "?" + q.Encode()
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// 복구 코드
+// /////////////////////////////////////////////////////////////////////////////
+/*
+2단계 인증 분실 대비 복구 코드. 사용자에게는 codes 를 한 번만 보여주고 hashes 만 저장
+코드가 충분히 무작위(50비트)라서 느린 해시 대신 SHA256 사용
+*/
+func NewRecoveryCodes(n int) (codes []string, hashes []string, err error) {
+	for i := 0; i < n; i++ {
+		b := make([]byte, 7)
+		if _, err := crand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		s := strings.ToLower(otpB32.EncodeToString(b))[:10]
+		code := s[:5] + "-" + s[5:]
+		codes = append(codes, code)
+		hashes = append(hashes, HashRecoveryCode(code))
+	}
+	return codes, hashes, nil
+}
+
+// 대소문자, 하이픈, 공백 무시
+func HashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	return SHA256(code)
+}
+
+// 일치한 해시의 인덱스, 없으면 -1. 사용된 해시는 호출 측에서 삭제할것
+func VerifyRecoveryCode(code string, hashes []string) int {
+	h := []byte(HashRecoveryCode(code))
+	found := -1
+	for i, v := range hashes {
+		if subtle.ConstantTimeCompare(h, []byte(v)) == 1 && found < 0 {
+			found = i
+		}
+	}
+	return found
+}
@@ -0,0 +1,118 @@
+package util
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// RFC 4226 부록 D
+func TestHOTP(t *testing.T) {
+	secret := []byte("12345678901234567890")
+	want := []string{"755224", "287082", "359152", "969429", "338314", "254676", "287922", "162583", "399871", "520489"}
+	for i, code := range want {
+		if got := HOTP(secret, uint64(i), 6); got != code {
+			t.Errorf("HOTP(%d) = %s, want %s", i, got, code)
+		}
+	}
+
+	next, ok := VerifyHOTP(secret, "969429", 1, 2, 6)
+	if !ok || next != 4 {
+		t.Errorf("VerifyHOTP in window = %d, %v", next, ok)
+	}
+	if _, ok := VerifyHOTP(secret, "969429", 0, 2, 6); ok {
+		t.Error("VerifyHOTP accepted code outside window")
+	}
+}
+
+// RFC 6238 부록 B
+func TestTOTP(t *testing.T) {
+	secrets := map[string][]byte{
+		"SHA1":   []byte("12345678901234567890"),
+		"SHA256": []byte("12345678901234567890123456789012"),
+		"SHA512": []byte("1234567890123456789012345678901234567890123456789012345678901234"),
+	}
+	tests := []struct {
+		unix int64
+		want map[string]string
+	}{
+		{59, map[string]string{"SHA1": "94287082", "SHA256": "46119246", "SHA512": "90693936"}},
+		{1111111109, map[string]string{"SHA1": "07081804", "SHA256": "68084774", "SHA512": "25091201"}},
+		{1234567890, map[string]string{"SHA1": "89005924", "SHA256": "91819424", "SHA512": "93441116"}},
+		{20000000000, map[string]string{"SHA1": "65353130", "SHA256": "77737706", "SHA512": "47863826"}},
+	}
+	for _, tt := range tests {
+		for alg, want := range tt.want {
+			tp := NewTOTP(secrets[alg])
+			tp.Digits, tp.Algorithm = 8, alg
+			if got, err := tp.At(time.Unix(tt.unix, 0)); err != nil || got != want {
+				t.Errorf("%s at %d = %s (%v), want %s", alg, tt.unix, got, err, want)
+			}
+		}
+	}
+}
+
+func TestTOTPVerify(t *testing.T) {
+	tp := NewTOTP([]byte("12345678901234567890"))
+	now := time.Unix(1234567890, 0)
+	prev, _ := tp.At(now.Add(-30 * time.Second))
+	old, _ := tp.At(now.Add(-90 * time.Second))
+
+	if step, ok := tp.Verify(prev, now); !ok || step != tp.step(now)-1 {
+		t.Errorf("previous step: %d, %v", step, ok)
+	}
+	if _, ok := tp.Verify(old, now); ok {
+		t.Error("accepted code outside skew")
+	}
+	if _, ok := tp.Verify("12345", now); ok {
+		t.Error("accepted short code")
+	}
+	tp.Algorithm = "MD5"
+	if _, err := tp.At(now); err == nil {
+		t.Error("unsupported algorithm accepted")
+	}
+}
+
+func TestTOTPURI(t *testing.T) {
+	secret, err := NewOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := DecodeOTPSecret(strings.ToLower(secret[:4]) + " " + secret[4:])
+	if err != nil || len(key) != 20 {
+		t.Fatalf("DecodeOTPSecret: %d bytes, %v", len(key), err)
+	}
+
+	u, err := url.Parse(NewTOTP(key).URI("My Service", "user@example.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	if u.Scheme != "otpauth" || u.Host != "totp" || u.Path != "/My Service:user@example.com" ||
+		q.Get("secret") != secret || q.Get("issuer") != "My Service" || q.Get("digits") != "6" {
+		t.Fatalf("URI = %s", u)
+	}
+}
+
+func TestRandomDigits(t *testing.T) {
+	for _, n := range []int{1, 6, 30} {
+		s, err := RandomDigits(n)
+		if err != nil || len(s) != n || strings.Trim(s, "0123456789") != "" {
+			t.Errorf("RandomDigits(%d) = %q, %v", n, s, err)
+		}
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, hashes, err := NewRecoveryCodes(5)
+	if err != nil || len(codes) != 5 || len(hashes) != 5 {
+		t.Fatalf("NewRecoveryCodes: %v, %v", codes, err)
+	}
+	if i := VerifyRecoveryCode(strings.ToUpper(strings.ReplaceAll(codes[3], "-", " ")), hashes); i != 3 {
+		t.Errorf("VerifyRecoveryCode = %d, want 3", i)
+	}
+	if i := VerifyRecoveryCode("aaaaa-bbbbb", hashes); i != -1 {
+		t.Errorf("VerifyRecoveryCode(unknown) = %d", i)
+	}
+}
@@ -0,0 +1,138 @@
+package util
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// 비밀번호 해시 (Argon2id, bcrypt 검증)
+// /////////////////////////////////////////////////////////////////////////////
+/*
+SHA256 은 비밀번호용이 아님. 저장은 HashPassword, 확인은 VerifyPassword 사용
+결과는 PHC 문자열 포맷:
+
+	$argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>
+
+기존 bcrypt 해시($2a$, $2b$, $2y$)도 검증 가능. 로그인 성공 시 NeedsRehash 가 true 면
+평문으로 다시 HashPassword 해서 저장하면 점진적으로 업그레이드 됨
+*/
+
+var ErrInvalidHash = errors.New("invalid password hash")
+
+type Argon2Params struct {
+	Memory  uint32 // KiB
+	Time    uint32 // 반복 횟수
+	Threads uint8
+	SaltLen uint32
+	KeyLen  uint32
+}
+
+// 서버 사양에 맞게 조정 가능. 바꾸면 기존 해시는 NeedsRehash 가 true
+var DefaultArgon2Params = Argon2Params{
+	Memory:  64 * 1024,
+	Time:    3,
+	Threads: 2,
+	SaltLen: 16,
+	KeyLen:  32,
+}
+
+var phcB64 = base64.RawStdEncoding
+
+func HashPassword(password string) (string, error) {
+	return HashPasswordWith(password, DefaultArgon2Params)
+}
+
+func HashPasswordWith(password string, p Argon2Params) (string, error) {
+	salt := make([]byte, p.SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, p.Time, p.Memory, p.Threads, p.KeyLen)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, p.Memory, p.Time, p.Threads,
+		phcB64.EncodeToString(salt), phcB64.EncodeToString(key),
+	), nil
+}
+
+func isBcrypt(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") ||
+		strings.HasPrefix(encoded, "$2b$") ||
+		strings.HasPrefix(encoded, "$2y$")
+}
+
+// PHC 문자열에서 파라미터, salt, 해시 추출
+func parseArgon2id(encoded string) (Argon2Params, []byte, []byte, error) {
+	var p Argon2Params
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[0] != "" || parts[1] != "argon2id" {
+		return p, nil, nil, ErrInvalidHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return p, nil, nil, ErrInvalidHash
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Time, &p.Threads); err != nil {
+		return p, nil, nil, ErrInvalidHash
+	}
+	if p.Time == 0 || p.Threads == 0 {
+		return p, nil, nil, ErrInvalidHash
+	}
+
+	salt, err := phcB64.DecodeString(parts[4])
+	if err != nil {
+		return p, nil, nil, ErrInvalidHash
+	}
+	key, err := phcB64.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return p, nil, nil, ErrInvalidHash
+	}
+	p.SaltLen, p.KeyLen = uint32(len(salt)), uint32(len(key))
+	return p, salt, key, nil
+}
+
+/*
+비밀번호 확인. 비교는 상수 시간
+불일치는 false, nil. 해시 형식이 잘못되었으면 ErrInvalidHash
+*/
+func VerifyPassword(password, encoded string) (bool, error) {
+	if isBcrypt(encoded) {
+		err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, nil
+		}
+		if err != nil {
+			return false, ErrInvalidHash
+		}
+		return true, nil
+	}
+
+	p, salt, key, err := parseArgon2id(encoded)
+	if err != nil {
+		return false, err
+	}
+	other := argon2.IDKey([]byte(password), salt, p.Time, p.Memory, p.Threads, p.KeyLen)
+	return subtle.ConstantTimeCompare(key, other) == 1, nil
+}
+
+// DefaultArgon2Params 기준으로 다시 해시해야 하는지 (bcrypt, 파라미터 변경, 알 수 없는 형식)
+func NeedsRehash(encoded string) bool {
+	return NeedsRehashWith(encoded, DefaultArgon2Params)
+}
+
+func NeedsRehashWith(encoded string, want Argon2Params) bool {
+	p, _, _, err := parseArgon2id(encoded)
+	if err != nil {
+		return true
+	}
+	return p.Memory != want.Memory || p.Time != want.Time || p.Threads != want.Threads ||
+		p.SaltLen != want.SaltLen || p.KeyLen != want.KeyLen
+}
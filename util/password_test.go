@@ -0,0 +1,126 @@
+package util
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// 테스트가 느려지지 않도록 작은 파라미터 사용
+var testArgon2Params = Argon2Params{Memory: 1024, Time: 1, Threads: 1, SaltLen: 16, KeyLen: 32}
+
+func TestHashPassword(t *testing.T) {
+	encoded, err := HashPasswordWith("s3cret", testArgon2Params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(encoded, "$argon2id$v=19$m=1024,t=1,p=1$") {
+		t.Fatalf("encoded = %s", encoded)
+	}
+	p, salt, key, err := parseArgon2id(encoded)
+	if err != nil || p != testArgon2Params || len(salt) != 16 || len(key) != 32 {
+		t.Fatalf("parse = %+v, %d, %d, %v", p, len(salt), len(key), err)
+	}
+	if ok, err := VerifyPassword("s3cret", encoded); !ok || err != nil {
+		t.Fatalf("VerifyPassword = %v, %v", ok, err)
+	}
+	if ok, err := VerifyPassword("S3cret", encoded); ok || err != nil {
+		t.Fatalf("wrong password = %v, %v", ok, err)
+	}
+
+	// salt 가 매번 달라야 함
+	again, _ := HashPasswordWith("s3cret", testArgon2Params)
+	if again == encoded {
+		t.Fatal("same hash for same password")
+	}
+}
+
+// 참조 구현(phc-winner-argon2) 테스트 벡터
+func TestVerifyPasswordArgon2Vector(t *testing.T) {
+	encoded := "$argon2id$v=19$m=65536,t=2,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc"
+	if ok, err := VerifyPassword("password", encoded); !ok || err != nil {
+		t.Fatalf("VerifyPassword = %v, %v", ok, err)
+	}
+}
+
+func TestVerifyPasswordBcrypt(t *testing.T) {
+	generated, err := bcrypt.GenerateFromPassword([]byte("legacy"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		password string
+		encoded  string
+		want     bool
+	}{
+		{"legacy", string(generated), true},
+		{"other", string(generated), false},
+		// jBCrypt 테스트 벡터
+		{"abc", "$2a$06$If6bvum7DFjUnE9p2uDeDu0YHzrHM6tf.iqN8.yx.jNN1ILEf7h0i", true},
+		{"abd", "$2a$06$If6bvum7DFjUnE9p2uDeDu0YHzrHM6tf.iqN8.yx.jNN1ILEf7h0i", false},
+	}
+	for _, tt := range tests {
+		ok, err := VerifyPassword(tt.password, tt.encoded)
+		if ok != tt.want || err != nil {
+			t.Errorf("VerifyPassword(%q, %s) = %v, %v", tt.password, tt.encoded, ok, err)
+		}
+		// bcrypt 는 항상 Argon2id 로 업그레이드 대상
+		if !NeedsRehash(tt.encoded) {
+			t.Errorf("NeedsRehash(%s) = false", tt.encoded)
+		}
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	encoded, _ := HashPasswordWith("pw", testArgon2Params)
+	if NeedsRehashWith(encoded, testArgon2Params) {
+		t.Fatal("same params need rehash")
+	}
+	change := []func(p *Argon2Params){
+		func(p *Argon2Params) { p.Memory *= 2 },
+		func(p *Argon2Params) { p.Time++ },
+		func(p *Argon2Params) { p.Threads++ },
+		func(p *Argon2Params) { p.SaltLen = 32 },
+		func(p *Argon2Params) { p.KeyLen = 64 },
+	}
+	for i, fn := range change {
+		want := testArgon2Params
+		fn(&want)
+		if !NeedsRehashWith(encoded, want) {
+			t.Errorf("change %d: NeedsRehash = false", i)
+		}
+	}
+	if !NeedsRehash("garbage") {
+		t.Error("unknown format does not need rehash")
+	}
+}
+
+func TestVerifyPasswordMalformed(t *testing.T) {
+	valid, _ := HashPasswordWith("pw", testArgon2Params)
+	parts := strings.Split(valid, "$")
+	with := func(i int, v string) string {
+		p := append([]string(nil), parts...)
+		p[i] = v
+		return strings.Join(p, "$")
+	}
+
+	for _, encoded := range []string{
+		"",
+		"plain-text",
+		"$argon2i$v=19$m=1024,t=1,p=1$c2FsdA$a2V5",
+		with(2, "v=16"),
+		with(3, "m=1024,t=0,p=1"),
+		with(3, "m=1024,t=1,p=0"),
+		with(3, "m=x,t=1,p=1"),
+		with(4, "!!"),
+		with(5, ""),
+		valid + "$extra",
+		"$2a$06$short",
+	} {
+		if ok, err := VerifyPassword("pw", encoded); ok || !errors.Is(err, ErrInvalidHash) {
+			t.Errorf("VerifyPassword(%q) = %v, %v", encoded, ok, err)
+		}
+	}
+}
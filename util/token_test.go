@@ -0,0 +1,123 @@
+package util
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTokenSigner(t *testing.T) {
+	ts := NewTokenSigner([]byte("key1"))
+	tok, err := ts.Sign("verify-email", map[string]any{"uid": 42}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var data struct {
+		UID int `json:"uid"`
+	}
+	if err := ts.Verify(tok, "verify-email", &data); err != nil || data.UID != 42 {
+		t.Fatalf("Verify = %+v, %v", data, err)
+	}
+
+	expired, _ := ts.Sign("verify-email", nil, -time.Second)
+	noExpiry, _ := ts.Sign("verify-email", nil, 0)
+	msg, sig, _ := strings.Cut(tok, ".")
+	flip := func(s string) string {
+		if s[0] == 'A' {
+			return "B" + s[1:]
+		}
+		return "A" + s[1:]
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		purpose string
+		err     error
+	}{
+		{"no expiry", noExpiry, "verify-email", nil},
+		{"other purpose", tok, "reset-password", ErrTokenInvalid},
+		{"expired", expired, "verify-email", ErrTokenExpired},
+		{"tampered payload", flip(msg) + "." + sig, "verify-email", ErrTokenInvalid},
+		{"tampered signature", msg + "." + flip(sig), "verify-email", ErrTokenInvalid},
+		{"no signature", msg, "verify-email", ErrTokenInvalid},
+		{"other key", mustSign(t, NewTokenSigner([]byte("key2")), "verify-email"), "verify-email", ErrTokenInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ts.Verify(tt.token, tt.purpose, nil); !errors.Is(err, tt.err) {
+				t.Fatalf("Verify = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func mustSign(t *testing.T, ts *TokenSigner, purpose string) string {
+	t.Helper()
+	tok, err := ts.Sign(purpose, nil, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tok
+}
+
+func TestTokenSignerRotation(t *testing.T) {
+	old := NewTokenSigner([]byte("old"))
+	tok := mustSign(t, old, "p")
+
+	rotated := NewTokenSigner([]byte("new"), []byte("old"))
+	if err := rotated.Verify(tok, "p", nil); err != nil {
+		t.Fatalf("token signed with old key: %v", err)
+	}
+	if err := old.Verify(mustSign(t, rotated, "p"), "p", nil); !errors.Is(err, ErrTokenInvalid) {
+		t.Fatalf("new token verified with old key only: %v", err)
+	}
+	if _, err := NewTokenSigner().Sign("p", nil, 0); err == nil {
+		t.Fatal("signed without key")
+	}
+}
+
+func TestSignURL(t *testing.T) {
+	ts := NewTokenSigner([]byte("key1"))
+	signed, err := ts.SignURL("https://example.com/downloads/report.pdf?v=2", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired, _ := ts.SignURL("https://example.com/downloads/report.pdf", -time.Second)
+
+	tests := []struct {
+		name string
+		url  string
+		edit func(u *url.URL)
+		err  error
+	}{
+		{"signed", signed, nil, nil},
+		{"other host", signed, func(u *url.URL) { u.Host = "cdn.example.com" }, nil},
+		{"other path", signed, func(u *url.URL) { u.Path = "/downloads/other.pdf" }, ErrTokenInvalid},
+		{"changed query", signed, func(u *url.URL) { setQuery(u, "v", "3") }, ErrTokenInvalid},
+		{"added query", signed, func(u *url.URL) { setQuery(u, "admin", "1") }, ErrTokenInvalid},
+		{"extended expiry", signed, func(u *url.URL) { setQuery(u, "expires", "99999999999") }, ErrTokenInvalid},
+		{"no signature", signed, func(u *url.URL) { setQuery(u, "signature", "") }, ErrTokenInvalid},
+		{"expired", expired, nil, ErrTokenExpired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, _ := url.Parse(tt.url)
+			if tt.edit != nil {
+				tt.edit(u)
+			}
+			if err := ts.VerifyURL(u); !errors.Is(err, tt.err) {
+				t.Fatalf("VerifyURL(%s) = %v, want %v", u, err, tt.err)
+			}
+		})
+	}
+}
+
+func setQuery(u *url.URL, k, v string) {
+	q := u.Query()
+	q.Set(k, v)
+	u.RawQuery = q.Encode()
+}
@@ -12,7 +12,7 @@ import (
 	"fmt"
 	"io"
 	"math"
-	"math/rand"
+	"math/big"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -74,12 +74,14 @@ func getBase62Index(char byte) int {
 // UUID
 // /////////////////////////////////////////////////////////////////////////////
 /*
-UUID는 지극히 낮은 확률로 중복될 수 있음.
-단순 태그/임시 식별자 용도로만 사용. 보안적 용도는 UUIDFromCryptoPackage
+하이픈 없는 대문자 32자 무작위 식별자 (crypto/rand)
+정렬이 필요한 기본키/요청 ID 는 UUIDv7 또는 ULIDString 사용 (id.go)
 */
 func UUID() string {
 	b := make([]byte, 16)
-	rand.New(rand.NewSource(time.Now().UnixNano())).Read(b)
+	if _, err := crand.Read(b); err != nil {
+		panic(fmt.Errorf("crypto/rand failed: %w", err))
+	}
 	return fmt.Sprintf("%X", b)
 }
 
@@ -106,7 +108,11 @@ func UUID4String(b []byte) string {
 특수용도임
 */
 func Ukey(num uint64) string {
-	r := uint64(62 + rand.Intn(3843-62+1))
+	n, err := crand.Int(crand.Reader, big.NewInt(3843-62+1))
+	if err != nil {
+		panic(fmt.Errorf("crypto/rand failed: %w", err))
+	}
+	r := uint64(62 + n.Int64())
 	mul := num * r
 	encodedMul := EncodeToBase62(mul)
 	encodedR := EncodeToBase62(r)
@@ -264,22 +270,28 @@ func Truncate(s string, max int) string {
 }
 
 /*
-보안적 무작위성이 필요하다면 crypto/rand 기반으로 교체
+n 자리 난수 (첫 자리는 0 이 아님). crypto/rand 기반
+인증 코드처럼 0 으로 시작해도 되는 경우는 RandomDigits 사용
 */
 func RandNDigits(n int) int {
 	if n <= 0 {
 		return 0
 	}
-	min := int(math.Pow10(n - 1))
-	max := int(math.Pow10(n)) - 1
-	return rand.Intn(max-min+1) + min
+	min := int64(math.Pow10(n - 1))
+	max := int64(math.Pow10(n)) - 1
+	v, err := crand.Int(crand.Reader, big.NewInt(max-min+1))
+	if err != nil {
+		panic(err)
+	}
+	return int(v.Int64() + min)
 }
 
 // HttpPost sends a POST request with given body and headers,
 // returns response body as bytes or error.
+// signers 는 헤더 설정 후 순서대로 호출됨 (예: HMACSigner)
 func HttpPost(
 	ctx context.Context, url string, body []byte,
-	headers map[string]string,
+	headers map[string]string, signers ...RequestSigner,
 ) ([]byte, error) {
 	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(body))
 	if err != nil {
@@ -290,6 +302,11 @@ func HttpPost(
 	for k, v := range headers {
 		req.Header.Set(k, v)
 	}
+	for _, sign := range signers {
+		if err := sign(req, body); err != nil {
+			return nil, err
+		}
+	}
 
 	client := &http.Client{
 		Timeout: 10 * time.Second,